@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/battery"
+	"github.com/odsod/bspwmrc/internal/notify"
+	"github.com/odsod/bspwmrc/internal/power"
+	"github.com/odsod/bspwmrc/internal/scheduler"
+	"golang.org/x/xerrors"
+)
+
+const batteryActionJob = "battery-action"
+
+// batteryMonitor alerts on low batteries and runs the configured action after a countdown.
+type batteryMonitor struct {
+	logger    *log.Logger
+	scheduler *scheduler.Scheduler
+	alerter   battery.Alerter
+}
+
+func (m *batteryMonitor) check(ctx context.Context) error {
+	config, err := battery.LoadConfig()
+	if err != nil {
+		return xerrors.Errorf("check batteries: %w", err)
+	}
+	m.alerter.Thresholds = config.Thresholds
+	system, err := power.Load()
+	if err != nil {
+		return xerrors.Errorf("check batteries: %w", err)
+	}
+	if len(system.Batteries) == 0 {
+		return nil
+	}
+	if !isDischarging(system) && m.scheduler.Cancel(batteryActionJob) {
+		m.logger.Printf("battery %s, cancelled %v", system.Status, config.Command)
+		if _, err := notify.Send(&notify.Notification{
+			Summary: "Battery " + strings.ToLower(system.Status),
+			Body:    "cancelled " + strings.Join(config.Command, " "),
+			Expire:  2 * time.Second,
+		}); err != nil {
+			return xerrors.Errorf("check batteries: %w", err)
+		}
+	}
+	level := m.alerter.Update(system.Charge(), system.Status, system.OnMains())
+	if level == battery.LevelNone {
+		return nil
+	}
+	m.logger.Printf("battery charge %.2f%%: %v", system.Charge()*100, level)
+	// Escalations replace the previous alert.
+	slot, err := notify.NewSlot("battery-alert")
+	if err != nil {
+		return xerrors.Errorf("check batteries: %w", err)
+	}
+	n := &notify.Notification{
+		Summary:  "Battery charge",
+		Body:     fmt.Sprintf("%.2f%%", system.Charge()*100),
+		Icon:     "battery-caution",
+		Category: "device",
+	}
+	switch level {
+	case battery.LevelWarning:
+		n.SetUrgency(notify.UrgencyNormal)
+		n.Expire = 5 * time.Second
+	case battery.LevelCritical:
+		n.SetUrgency(notify.UrgencyCritical)
+		n.Expire = 10 * time.Second
+	case battery.LevelAction:
+		m.scheduleAction(ctx, slot, n, config)
+		return nil
+	}
+	if _, err := slot.Send(n); err != nil {
+		return xerrors.Errorf("check batteries: %w", err)
+	}
+	return nil
+}
+
+const (
+	batteryActionNow    = "now"
+	batteryActionCancel = "cancel"
+)
+
+// scheduleAction schedules the battery action after a countdown notification that lets the user
+// run it right away or cancel it.
+func (m *batteryMonitor) scheduleAction(ctx context.Context, slot *notify.Slot, n *notify.Notification, config *battery.Config) {
+	command := strings.Join(config.Command, " ")
+	action := func(ctx context.Context) error {
+		// Power may have been plugged in since the last check.
+		system, err := power.Load()
+		if err != nil {
+			return xerrors.Errorf("battery action %s: %w", command, err)
+		}
+		if !isDischarging(system) {
+			m.logger.Printf("battery %s, skipping %v", system.Status, config.Command)
+			return nil
+		}
+		m.logger.Printf("battery action: %v", config.Command)
+		if err := exec.CommandContext(ctx, config.Command[0], config.Command[1:]...).Run(); err != nil {
+			return xerrors.Errorf("battery action %s: %w", command, err)
+		}
+		return nil
+	}
+	m.scheduler.After(batteryActionJob, config.Countdown, action)
+	n.Body = fmt.Sprintf("%s\n%s in %v", n.Body, command, config.Countdown)
+	n.SetUrgency(notify.UrgencyCritical)
+	n.Expire = config.Countdown
+	n.Actions = []notify.Action{
+		{Key: batteryActionNow, Label: command + " now"},
+		{Key: batteryActionCancel, Label: "Cancel"},
+	}
+	go func() {
+		choice, err := slot.SendAndWait(ctx, n)
+		if err != nil {
+			m.logger.Printf("battery action: %v", err)
+			return
+		}
+		switch choice {
+		case batteryActionNow:
+			// Only run the action if it is still pending, it may have been cancelled meanwhile.
+			if m.cancel() {
+				m.scheduler.After(batteryActionJob, 0, action)
+			}
+		case batteryActionCancel:
+			if m.cancel() {
+				m.logger.Printf("battery action cancelled: %v", config.Command)
+			}
+		}
+	}()
+}
+
+// isDischarging reports whether the system runs on battery power alone.
+func isDischarging(system *power.System) bool {
+	return system.Status == "Discharging" && !system.OnMains()
+}
+
+// cancel stops a pending battery action and reports whether there was one.
+func (m *batteryMonitor) cancel() bool {
+	return m.scheduler.Cancel(batteryActionJob)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/control"
+	"github.com/odsod/bspwmrc/internal/lockfile"
+	"github.com/odsod/bspwmrc/internal/notify"
+	"github.com/odsod/bspwmrc/internal/scheduler"
+	"github.com/odsod/bspwmrc/internal/scratchpad"
+	"github.com/odsod/bspwmrc/internal/supervisor"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+var (
+	errNoSuchScratchpad      = xerrors.New("no such scratchpad")
+	errNoSuchScratchpadGroup = xerrors.New("no such scratchpad group")
+)
+
+func init() {
+	control.RegisterError("launch-in-progress", scratchpad.ErrLaunchInProgress)
+	control.RegisterError("no-such-scratchpad", errNoSuchScratchpad)
+	control.RegisterError("no-such-scratchpad-group", errNoSuchScratchpadGroup)
+}
+
+func services() []supervisor.Service {
+	return []supervisor.Service{
+		{
+			Name:         "sxhkd",
+			Argv:         []string{"sxhkd", "-t", "1"},
+			Reload:       supervisor.ReloadSignal,
+			ReloadSignal: syscall.SIGUSR1,
+			Restart:      supervisor.RestartAlways,
+		},
+		{
+			Name:    "dunst",
+			Argv:    []string{"dunst", "-geometry", "200x5-30+30"},
+			Reload:  supervisor.ReloadRestart,
+			Restart: supervisor.RestartAlways,
+		},
+		{
+			Name: "xcape",
+			// Keep xcape in the foreground so that it can be supervised.
+			Argv:    []string{"xcape", "-d", "-e", "Control_L=Escape;Hyper_L=Tab", "-t", "250"},
+			Reload:  supervisor.ReloadRestart,
+			Restart: supervisor.RestartAlways,
+		},
+		{
+			Name:    "urxvtd",
+			Argv:    []string{"urxvtd", "--quiet", "--opendisplay"},
+			Reload:  supervisor.ReloadStartIfMissing,
+			Restart: supervisor.RestartOnFailure,
+		},
+	}
+}
+
+// forward runs args in the daemon and reports whether it was handled there.
+func forward(logger *log.Logger, args []string) bool {
+	path, err := control.SocketPath()
+	if err != nil {
+		panic(err)
+	}
+	output, err := control.Call(context.Background(), path, args...)
+	if xerrors.Is(err, control.ErrNoDaemon) {
+		return false
+	}
+	if output != "" {
+		if _, err := os.Stdout.WriteString(output); err != nil {
+			panic(err)
+		}
+	}
+	if err != nil {
+		if isScratchpadUserError(err) {
+			logger.Printf("%v", err)
+			return true
+		}
+		panic(err)
+	}
+	return true
+}
+
+// daemon supervises services and serves forwarded subcommands until terminated.
+// When a daemon is already running it is asked to reload instead.
+func daemon(logger *log.Logger) {
+	logger.Printf("daemon")
+	runtimeDir, err := xdg.RuntimeDir()
+	if err != nil {
+		panic(err)
+	}
+	lockPath := filepath.Join(runtimeDir, "bspwmrc", "daemon.lock")
+	l, err := lockfile.Acquire(lockPath)
+	if xerrors.Is(err, lockfile.ErrLocked) {
+		pid, err := lockfile.Owner(lockPath)
+		if err != nil {
+			panic(err)
+		}
+		logger.Printf("reloading daemon pid=%d", pid)
+		if err := syscall.Kill(pid, syscall.SIGHUP); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := l.Release(); err != nil {
+			panic(err)
+		}
+	}()
+	c, err := bspc.NewClient()
+	if err != nil {
+		panic(err)
+	}
+	d := &daemonState{
+		logger:    logger,
+		client:    c,
+		sps:       loadScratchpads(logger),
+		scheduler: scheduler.New(logger, notifyJobError(logger)),
+	}
+	d.batteries = &batteryMonitor{logger: logger, scheduler: d.scheduler}
+	js, err := jobs(logger, d.batteries)
+	if err != nil {
+		panic(err)
+	}
+	schedule(logger, d.scheduler, js)
+	socketPath, err := control.SocketPath()
+	if err != nil {
+		panic(err)
+	}
+	server, err := control.Listen(socketPath, logger, d.handle)
+	if err != nil {
+		panic(err)
+	}
+	s := supervisor.New(logger, services()...)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if sig != syscall.SIGHUP {
+				cancel()
+				return
+			}
+			logger.Printf("reloading daemon")
+			d.reload()
+			schedule(logger, d.scheduler, js)
+			if err := s.Reload(); err != nil {
+				logger.Printf("reload services: %v", err)
+			}
+			notifyReloaded(logger)
+		}
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.Started():
+			notifyReloaded(logger)
+		}
+	}()
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		if err := s.Run(ctx); err != nil {
+			logger.Printf("daemon: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		if err := server.Serve(ctx); err != nil {
+			logger.Printf("daemon: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := d.scheduler.Run(ctx); err != nil {
+			logger.Printf("daemon: %v", err)
+		}
+	}()
+	wg.Wait()
+}
+
+// notifyReloaded is sent once the services are up, so that a restarted dunst shows it.
+func notifyReloaded(logger *log.Logger) {
+	if _, err := notify.Send(&notify.Notification{
+		Summary: "bspwmrc",
+		Body:    "desktop reloaded",
+		Expire:  time.Second,
+	}); err != nil {
+		logger.Printf("daemon: %v", err)
+	}
+}
+
+type daemonState struct {
+	logger *log.Logger
+	client *bspc.Client
+	// requestMu serializes requests that change the window manager state.
+	requestMu sync.Mutex
+	mu        sync.Mutex
+	sps       map[string]*scratchpad.S
+	scheduler *scheduler.Scheduler
+	batteries *batteryMonitor
+}
+
+func (d *daemonState) handle(ctx context.Context, args []string) (string, error) {
+	d.logger.Printf("daemon %v", args)
+	if len(args) == 0 {
+		return "", xerrors.New("empty request")
+	}
+	switch {
+	case args[0] == "toggle-scratchpad" && len(args) == 2:
+		return "", d.withScratchpadState(ctx, func(state *wm.State, sps map[string]*scratchpad.S) (*scratchpad.S, error) {
+			if _, ok := sps[args[1]]; !ok {
+				return nil, xerrors.Errorf("%v: %w", args[1], errNoSuchScratchpad)
+			}
+			return scratchpad.PrepareToggle(ctx, d.client, state, sps, args[1])
+		})
+	case args[0] == "toggle-scratchpad-group" && len(args) == 2:
+		return "", d.withScratchpadState(ctx, func(state *wm.State, sps map[string]*scratchpad.S) (*scratchpad.S, error) {
+			if len(scratchpad.GroupMembers(sps, args[1])) == 0 {
+				return nil, xerrors.Errorf("%v: %w", args[1], errNoSuchScratchpadGroup)
+			}
+			return scratchpad.PrepareToggleGroup(ctx, d.client, state, sps, args[1])
+		})
+	case args[0] == "prev":
+		d.requestMu.Lock()
+		defer d.requestMu.Unlock()
+		return "", focusPrev(ctx, d.client)
+	case args[0] == "clock":
+		return "", showClock()
+	case args[0] == "battery-charge":
+		return "", showBatteryCharge()
+	case args[0] == "volume" && len(args) == 2:
+		return "", changeVolume(args[1])
+	case args[0] == "brightness" && len(args) == 2:
+		return "", changeBrightness(args[1])
+	case args[0] == "jobs":
+		return listJobs(d.scheduler)
+	case args[0] == "battery-cancel":
+		if !d.batteries.cancel() {
+			return "no pending battery action\n", nil
+		}
+		return "cancelled battery action\n", nil
+	}
+	return "", xerrors.Errorf("unhandled: %+v", args)
+}
+
+// withScratchpadState runs fn on fresh state while holding the request lock. A scratchpad returned by fn is
+// launched after the lock is released, so that waiting for its window does not hold up other requests, and a
+// repeated toggle meanwhile fails with scratchpad.ErrLaunchInProgress.
+func (d *daemonState) withScratchpadState(
+	ctx context.Context,
+	fn func(*wm.State, map[string]*scratchpad.S) (*scratchpad.S, error),
+) error {
+	launch, err := d.prepareScratchpads(ctx, fn)
+	if err != nil || launch == nil {
+		return err
+	}
+	return launch.Launch(ctx, d.client)
+}
+
+func (d *daemonState) prepareScratchpads(
+	ctx context.Context,
+	fn func(*wm.State, map[string]*scratchpad.S) (*scratchpad.S, error),
+) (*scratchpad.S, error) {
+	d.requestMu.Lock()
+	defer d.requestMu.Unlock()
+	// The state is queried for every request, since bspwm changes it behind the daemon's back.
+	state, err := wm.Load(ctx, d.client)
+	if err != nil {
+		return nil, xerrors.Errorf("daemon: %w", err)
+	}
+	d.mu.Lock()
+	sps := d.sps
+	d.mu.Unlock()
+	return fn(state, sps)
+}
+
+func (d *daemonState) reload() {
+	sps := loadScratchpads(d.logger)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sps = sps
+}
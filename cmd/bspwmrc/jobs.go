@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"text/tabwriter"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/notify"
+	"github.com/odsod/bspwmrc/internal/scheduler"
+	"github.com/odsod/bspwmrc/internal/wallpaper"
+	"github.com/odsod/bspwmrc/internal/xrdb"
+	"golang.org/x/xerrors"
+)
+
+func jobs(logger *log.Logger, batteries *batteryMonitor) ([]scheduler.Job, error) {
+	wallpaperDir, err := wallpaper.Dir()
+	if err != nil {
+		return nil, xerrors.Errorf("jobs: %w", err)
+	}
+	rotator := &wallpaper.Rotator{Dir: wallpaperDir}
+	xresources := &xresourcesWatcher{logger: logger}
+	power := &powerMonitor{logger: logger}
+	return []scheduler.Job{
+		{
+			Name:     "battery",
+			Interval: time.Minute,
+			Jitter:   5 * time.Second,
+			Run:      batteries.check,
+		},
+		{
+			Name:     "power",
+			Interval: 5 * time.Second,
+			Run:      power.check,
+		},
+		{
+			Name:     "wallpaper",
+			Interval: 30 * time.Minute,
+			Jitter:   time.Minute,
+			Run: func(context.Context) error {
+				return rotator.Next()
+			},
+		},
+		{
+			Name:     "xrdb",
+			Interval: 10 * time.Second,
+			Run: func(context.Context) error {
+				return xresources.check()
+			},
+		},
+	}, nil
+}
+
+func schedule(logger *log.Logger, s *scheduler.Scheduler, js []scheduler.Job) {
+	config, err := scheduler.LoadConfig()
+	if err != nil {
+		logger.Printf("jobs config: %v", err)
+		if _, err := notify.Send(&notify.Notification{
+			Summary: "bspwmrc",
+			Body:    "invalid jobs config, using defaults",
+			Expire:  2 * time.Second,
+		}); err != nil {
+			logger.Printf("jobs config: %v", err)
+		}
+		config = &scheduler.Config{}
+	}
+	for _, job := range js {
+		job, enabled := config.Apply(job)
+		if !enabled {
+			s.Cancel(job.Name)
+			continue
+		}
+		if err := s.Add(job); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func notifyJobError(logger *log.Logger) func(string, error) {
+	return func(job string, err error) {
+		if _, err := notify.Send(&notify.Notification{
+			Summary: fmt.Sprintf("bspwmrc job %s failed", job),
+			Body:    err.Error(),
+			Expire:  5 * time.Second,
+		}); err != nil {
+			logger.Printf("job %s: %v", job, err)
+		}
+	}
+}
+
+func listJobs(s *scheduler.Scheduler) (string, error) {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "NAME\tINTERVAL\tLAST RUN\tNEXT RUN\tLAST ERROR"); err != nil {
+		return "", xerrors.Errorf("list jobs: %w", err)
+	}
+	for _, status := range s.Status() {
+		interval := "once"
+		if status.Interval > 0 {
+			interval = status.Interval.String()
+		}
+		lastRun := "-"
+		if !status.LastRun.IsZero() {
+			lastRun = status.LastRun.Format("15:04:05")
+		}
+		nextRun := status.NextRun.Format("15:04:05")
+		if status.Running {
+			nextRun = "running"
+		}
+		lastErr := "-"
+		if status.LastError != nil {
+			lastErr = status.LastError.Error()
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", status.Name, interval, lastRun, nextRun, lastErr); err != nil {
+			return "", xerrors.Errorf("list jobs: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", xerrors.Errorf("list jobs: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// xresourcesWatcher reapplies the bspwm config when the X resources change, e.g. after xrdb -merge.
+type xresourcesWatcher struct {
+	logger *log.Logger
+	last   *xrdb.Resources
+}
+
+func (w *xresourcesWatcher) check() error {
+	xresources, err := xrdb.Query()
+	if err != nil {
+		return xerrors.Errorf("check xresources: %w", err)
+	}
+	if w.last != nil && *w.last != *xresources {
+		w.logger.Printf("xresources changed, reconfiguring bspwm")
+		if err := configureBspwm(w.logger, xresources); err != nil {
+			// Keep the previous resources so that the next check tries again.
+			return xerrors.Errorf("check xresources: %w", err)
+		}
+	}
+	w.last = xresources
+	return nil
+}
@@ -2,21 +2,30 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"log/syslog"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
-	"github.com/odsod/bspwmrc/internal/battery"
 	"github.com/odsod/bspwmrc/internal/bspc"
-	"github.com/odsod/bspwmrc/internal/childprocess"
 	"github.com/odsod/bspwmrc/internal/notify"
+	"github.com/odsod/bspwmrc/internal/power"
 	"github.com/odsod/bspwmrc/internal/scratchpad"
+	"github.com/odsod/bspwmrc/internal/session"
 	"github.com/odsod/bspwmrc/internal/wm"
+	"github.com/odsod/bspwmrc/internal/xprop"
 	"github.com/odsod/bspwmrc/internal/xrdb"
+	"github.com/shirou/gopsutil/process"
+	"golang.org/x/xerrors"
 )
 
 func main() {
@@ -30,6 +39,10 @@ func main() {
 		}
 	}()
 	logger := log.New(s, "", log.Lshortfile)
+	// Popups must never take down a command, e.g. while dunst is being restarted.
+	notify.SetDefault(notify.NewNotifier(
+		notify.WithFallback(notify.LoggerSink(log.New(io.MultiWriter(s, os.Stderr), "", 0))),
+	))
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Printf("panic: %+v", r)
@@ -39,18 +52,67 @@ func main() {
 	switch {
 	case len(args) == 0:
 		config(logger)
+	case args[0] == "daemon":
+		daemon(logger)
 	case args[0] == "toggle-scratchpad" && len(args) == 2:
-		toggleScratchpad(logger, args[1])
-	case args[0] == "cron":
-		cron(logger)
+		if !forward(logger, args) {
+			toggleScratchpad(logger, args[1])
+		}
+	case args[0] == "toggle-scratchpad-group" && len(args) == 2:
+		if !forward(logger, args) {
+			toggleScratchpadGroup(logger, args[1])
+		}
+	case args[0] == "scratchpad" && len(args) == 2 && args[1] == "list":
+		listScratchpads(logger)
+	case args[0] == "scratchpad" && len(args) == 2 && args[1] == "status":
+		scratchpadStatus(logger)
+	case args[0] == "scratchpad" && len(args) == 2 && args[1] == "hide-all":
+		hideAllScratchpads(logger)
+	case args[0] == "scratchpad" && len(args) == 2 && args[1] == "show-all":
+		showAllScratchpads(logger)
+	case args[0] == "scratchpad" && len(args) == 3 && args[1] == "kill":
+		killScratchpad(logger, args[2])
+	case args[0] == "jobs" && len(args) == 1, args[0] == "battery-cancel" && len(args) == 1:
+		if !forward(logger, args) {
+			fmt.Fprintln(os.Stderr, "bspwmrc: daemon not running")
+		}
 	case args[0] == "run":
 		run(logger)
 	case args[0] == "clock":
-		clock(logger)
+		if !forward(logger, args) {
+			clock(logger)
+		}
 	case args[0] == "battery-charge":
-		batteryCharge(logger)
+		if !forward(logger, args) {
+			batteryCharge(logger)
+		}
 	case args[0] == "prev":
-		prev(logger)
+		if !forward(logger, args) {
+			prev(logger)
+		}
+	case args[0] == "volume" && len(args) == 2 && (args[1] == "up" || args[1] == "down" || args[1] == "mute"):
+		if !forward(logger, args) {
+			volume(logger, args[1])
+		}
+	case args[0] == "brightness" && len(args) == 2 && (args[1] == "up" || args[1] == "down"):
+		if !forward(logger, args) {
+			brightness(logger, args[1])
+		}
+	case args[0] == "watch":
+		watch(logger)
+	case args[0] == "session" && len(args) >= 2 && len(args) <= 3:
+		name := session.DefaultName
+		if len(args) == 3 {
+			name = args[2]
+		}
+		switch args[1] {
+		case "save":
+			sessionSave(logger, name)
+		case "restore":
+			sessionRestore(logger, name)
+		default:
+			logger.Printf("unhandled: %+v", args)
+		}
 	default:
 		logger.Printf("unhandled: %+v", args)
 	}
@@ -63,7 +125,42 @@ func config(logger *log.Logger) {
 	if err != nil {
 		panic(err)
 	}
-	// Configure bspwm
+	if err := configureBspwm(logger, xresources); err != nil {
+		panic(err)
+	}
+	for _, cmd := range [][]string{
+		{"setxkbmap", "custom"},
+		{"xsetroot", "-cursor_name", "left_ptr"},
+		{"feh", "--bg-scale", "/usr/share/backgrounds/ubuntu-default-greyscale-wallpaper.png"},
+	} {
+		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
+			panic(err)
+		}
+	}
+	// The daemon reloads an already running instance and notifies once its services are up.
+	if err := startDaemon(); err != nil {
+		panic(err)
+	}
+}
+
+// startDaemon runs the daemon detached from config, which returns right away.
+func startDaemon() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return xerrors.Errorf("start daemon: %w", err)
+	}
+	cmd := exec.Command(executable, "daemon")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return xerrors.Errorf("start daemon: %w", err)
+	}
+	if err := cmd.Process.Release(); err != nil {
+		return xerrors.Errorf("start daemon: %w", err)
+	}
+	return nil
+}
+
+func configureBspwm(logger *log.Logger, xresources *xrdb.Resources) error {
 	for _, cmd := range [][]string{
 		{"config", "focus_follows_pointer", "true"},
 		{"config", "pointer_follows_focus", "true"},
@@ -82,71 +179,184 @@ func config(logger *log.Logger) {
 	} {
 		logger.Printf("bspc %v", cmd)
 		if _, err := bspc.Run(cmd...); err != nil {
-			panic(err)
+			return xerrors.Errorf("configure bspwm: %w", err)
 		}
 	}
-	// Load processes
-	cps, err := childprocess.LoadAll()
+	return nil
+}
+
+func toggleScratchpad(logger *log.Logger, name string) {
+	logger.Printf("toggle-scratchpad name=%s", name)
+	sps := loadScratchpads(logger)
+	if _, ok := sps[name]; !ok {
+		logger.Printf("no such scratchpad: %v", name)
+		return
+	}
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		return scratchpad.Toggle(ctx, c, state, sps, name)
+	})
+}
+
+func toggleScratchpadGroup(logger *log.Logger, group string) {
+	logger.Printf("toggle-scratchpad-group group=%s", group)
+	sps := loadScratchpads(logger)
+	if len(scratchpad.GroupMembers(sps, group)) == 0 {
+		logger.Printf("no such scratchpad group: %v", group)
+		return
+	}
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		return scratchpad.ToggleGroup(ctx, c, state, sps, group)
+	})
+}
+
+func withScratchpadState(logger *log.Logger, fn func(context.Context, *bspc.Client, *wm.State) error) {
+	ctx := context.Background()
+	c, err := bspc.NewClient()
 	if err != nil {
 		panic(err)
 	}
-	if err := cps.Reload(); err != nil {
+	state, err := wm.Load(ctx, c)
+	if err != nil {
 		panic(err)
 	}
-	for _, cmd := range [][]string{
-		{"setxkbmap", "custom"},
-		{"xsetroot", "-cursor_name", "left_ptr"},
-		{"feh", "--bg-scale", "/usr/share/backgrounds/ubuntu-default-greyscale-wallpaper.png"},
-	} {
-		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
-			panic(err)
+	if err := fn(ctx, c, state); err != nil {
+		if isScratchpadUserError(err) {
+			logger.Printf("scratchpad: %v", err)
+			return
 		}
-	}
-	if err := notify.Send("bspwmrc", "desktop reloaded", time.Second); err != nil {
 		panic(err)
 	}
 }
 
-func toggleScratchpad(logger *log.Logger, name string) {
-	logger.Printf("toggle-scratchpad name=%s", name)
-	sp, ok := scratchpad.All()[name]
-	if !ok {
-		logger.Printf("no such scratchpad: %v", name)
-		return
+func isScratchpadUserError(err error) bool {
+	var bspcErr *bspc.Error
+	return xerrors.Is(err, scratchpad.ErrLaunchInProgress) ||
+		xerrors.Is(err, errNoSuchScratchpad) ||
+		xerrors.Is(err, errNoSuchScratchpadGroup) ||
+		xerrors.As(err, &bspcErr)
+}
+
+func loadScratchpads(logger *log.Logger) map[string]*scratchpad.S {
+	sps, err := scratchpad.Load()
+	if err == nil {
+		return sps
 	}
-	state, err := wm.LoadState()
-	if err != nil {
+	var configErr *scratchpad.ConfigError
+	if xerrors.As(err, &configErr) {
+		for _, fieldErr := range configErr.Errors {
+			logger.Printf("scratchpad config: %v", fieldErr)
+			fmt.Fprintf(os.Stderr, "%s: %v\n", configErr.Path, fieldErr)
+		}
+	} else {
+		logger.Printf("scratchpad config: %v", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	if _, err := notify.Send(&notify.Notification{
+		Summary: "bspwmrc",
+		Body:    "invalid scratchpad config, using built-ins",
+		Expire:  2 * time.Second,
+	}); err != nil {
+		logger.Printf("scratchpad config: %v", err)
+	}
+	return scratchpad.All()
+}
+
+func listScratchpads(logger *log.Logger) {
+	logger.Printf("scratchpad list")
+	sps := loadScratchpads(logger)
+	keys := make([]string, 0, len(sps))
+	for key := range sps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "KEY\tNAME\tCLASS\tINSTANCE\tCOMMAND"); err != nil {
 		panic(err)
 	}
-	searchResult, ok := sp.SearchState(state)
-	if !ok {
-		if err := sp.Start(); err != nil {
+	for _, key := range keys {
+		sp := sps[key]
+		if _, err := fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\n", key, sp.Name, sp.ClassName, sp.InstanceName, strings.Join(sp.Cmd, " "),
+		); err != nil {
 			panic(err)
 		}
-		return
 	}
-	if err := searchResult.Toggle(state); err != nil {
+	if err := w.Flush(); err != nil {
 		panic(err)
 	}
 }
 
-func cron(logger *log.Logger) {
-	logger.Printf("cron")
-	bs, err := battery.LoadAll()
-	if err != nil {
-		panic(err)
-	}
-	for _, b := range bs {
-		if b.Charge() < 0.1 {
-			if err := notify.Send(
-				fmt.Sprintf("%s charge", b.Name),
-				fmt.Sprintf("%.2f%%", b.Charge()*100),
-				2*time.Second,
+func scratchpadStatus(logger *log.Logger) {
+	logger.Printf("scratchpad status")
+	sps := loadScratchpads(logger)
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		if _, err := fmt.Fprintln(w, "KEY\tNAME\tSTATE\tDESKTOP\tMONITOR"); err != nil {
+			return err
+		}
+		for _, status := range scratchpad.StatusAll(state, sps) {
+			desktop, monitor := "-", "-"
+			if status.SearchResult != nil {
+				desktop, monitor = status.SearchResult.Desktop.Name, status.SearchResult.Monitor.Name
+			}
+			if _, err := fmt.Fprintf(
+				w, "%s\t%s\t%s\t%s\t%s\n", status.Key, status.Scratchpad.Name, status.State, desktop, monitor,
 			); err != nil {
-				panic(err)
+				return err
 			}
 		}
+		return w.Flush()
+	})
+}
+
+func hideAllScratchpads(logger *log.Logger) {
+	logger.Printf("scratchpad hide-all")
+	sps := loadScratchpads(logger)
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		n, err := scratchpad.HideAll(ctx, c, state, sps)
+		if err != nil {
+			return err
+		}
+		logger.Printf("hid %d scratchpads", n)
+		_, err = fmt.Printf("hid %d scratchpads\n", n)
+		return err
+	})
+}
+
+func showAllScratchpads(logger *log.Logger) {
+	logger.Printf("scratchpad show-all")
+	sps := loadScratchpads(logger)
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		n, err := scratchpad.ShowAll(ctx, c, state, sps)
+		if err != nil {
+			return err
+		}
+		logger.Printf("showed %d scratchpads", n)
+		_, err = fmt.Printf("showed %d scratchpads\n", n)
+		return err
+	})
+}
+
+func killScratchpad(logger *log.Logger, name string) {
+	logger.Printf("scratchpad kill name=%s", name)
+	sps := loadScratchpads(logger)
+	sp, ok := sps[name]
+	if !ok {
+		logger.Printf("no such scratchpad: %v", name)
+		return
 	}
+	withScratchpadState(logger, func(ctx context.Context, c *bspc.Client, state *wm.State) error {
+		if err := scratchpad.Kill(ctx, c, state, sps, name); err != nil {
+			if xerrors.Is(err, scratchpad.ErrNotRunning) {
+				logger.Printf("scratchpad kill: %v", err)
+				_, err := notify.Send(&notify.Notification{Summary: sp.Name, Body: "not running", Expire: time.Second})
+				return err
+			}
+			return err
+		}
+		_, err := notify.Send(&notify.Notification{Summary: sp.Name, Body: "killed", Expire: time.Second})
+		return err
+	})
 }
 
 func run(logger *log.Logger) {
@@ -159,34 +369,200 @@ func run(logger *log.Logger) {
 
 func prev(logger *log.Logger) {
 	logger.Printf("prev")
-	if _, err := bspc.Run("node", "--focus", "prev"); err != nil {
+	c, err := bspc.NewClient()
+	if err != nil {
+		panic(err)
+	}
+	if err := focusPrev(context.Background(), c); err != nil {
+		var bspcErr *bspc.Error
+		if xerrors.As(err, &bspcErr) {
+			logger.Printf("prev: %v", bspcErr)
+			return
+		}
+		panic(err)
+	}
+}
+
+func focusPrev(ctx context.Context, c *bspc.Client) error {
+	if _, err := c.Exec(ctx, bspc.Node().Focus(bspc.NodeDesc(bspc.Prev))); err != nil {
+		return xerrors.Errorf("focus prev: %w", err)
+	}
+	return nil
+}
+
+func watch(logger *log.Logger) {
+	logger.Printf("watch")
+	ctx := context.Background()
+	c, err := bspc.NewClient()
+	if err != nil {
+		panic(err)
+	}
+	sub, err := c.Subscribe(
+		ctx,
+		"node_add", "node_remove", "node_transfer", "node_swap", "node_focus", "node_flag",
+		"desktop_focus", "desktop_layout", "monitor_focus",
+	)
+	if err != nil {
+		panic(err)
+	}
+	state, err := wm.Load(ctx, c)
+	if err != nil {
+		panic(err)
+	}
+	for range sub.Events() {
+		next, err := wm.Load(ctx, c)
+		if err != nil {
+			panic(err)
+		}
+		for _, change := range wm.Diff(state, next) {
+			fmt.Println(change)
+		}
+		state = next
+	}
+	if err := sub.Err(); err != nil {
+		panic(err)
+	}
+}
+
+func sessionSave(logger *log.Logger, name string) {
+	logger.Printf("session save name=%s", name)
+	state, err := wm.LoadState()
+	if err != nil {
+		panic(err)
+	}
+	s := session.Capture(state, func(windowID int) ([]string, error) {
+		pid, err := xprop.PID(windowID)
+		if err != nil {
+			return nil, err
+		}
+		p, err := process.NewProcess(int32(pid))
+		if err != nil {
+			return nil, err
+		}
+		return p.CmdlineSlice()
+	})
+	if err := session.Save(name, s); err != nil {
+		panic(err)
+	}
+	if _, err := notify.Send(&notify.Notification{
+		Summary: "bspwmrc",
+		Body:    fmt.Sprintf("session %s saved", name),
+		Expire:  time.Second,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func sessionRestore(logger *log.Logger, name string) {
+	logger.Printf("session restore name=%s", name)
+	s, err := session.Load(name)
+	if err != nil {
+		panic(err)
+	}
+	c, err := bspc.NewClient()
+	if err != nil {
+		panic(err)
+	}
+	if err := session.Restore(context.Background(), logger, c, s); err != nil {
+		panic(err)
+	}
+	if _, err := notify.Send(&notify.Notification{
+		Summary: "bspwmrc",
+		Body:    fmt.Sprintf("session %s restored", name),
+		Expire:  time.Second,
+	}); err != nil {
 		panic(err)
 	}
 }
 
 func clock(logger *log.Logger) {
 	logger.Printf("clock")
+	if err := showClock(); err != nil {
+		panic(err)
+	}
+}
+
+func showClock() error {
 	now := time.Now()
 	nowDate := now.Format("Mon Jan _2")
 	nowTime := now.Format("15:04:05 MST")
-	if err := notify.Send(nowDate, nowTime, 2*time.Second); err != nil {
-		panic(err)
+	slot, err := notify.NewSlot("clock")
+	if err != nil {
+		return xerrors.Errorf("show clock: %w", err)
 	}
+	if _, err := slot.Send(&notify.Notification{
+		Summary: nowDate,
+		Body:    nowTime,
+		Expire:  2 * time.Second,
+	}); err != nil {
+		return xerrors.Errorf("show clock: %w", err)
+	}
+	return nil
 }
 
 func batteryCharge(logger *log.Logger) {
 	logger.Printf("battery-charge")
-	batteries, err := battery.LoadAll()
-	if err != nil {
+	if err := showBatteryCharge(); err != nil {
 		panic(err)
 	}
+}
+
+func showBatteryCharge() error {
+	system, err := power.Load()
+	if err != nil {
+		return xerrors.Errorf("show battery charge: %w", err)
+	}
+	summary := "Battery"
+	if system.OnMains() {
+		summary = "Battery (on mains)"
+	}
 	var buf bytes.Buffer
-	for _, b := range batteries {
+	if len(system.Batteries) > 1 {
+		if _, err := fmt.Fprintf(&buf, "total %s:\n%.2f%%\n", system.Status, system.Charge()*100); err != nil {
+			return xerrors.Errorf("show battery charge: %w", err)
+		}
+	}
+	for _, b := range system.Batteries {
 		if _, err := fmt.Fprintf(&buf, "%s %s:\n%.2f%%\n", b.Name, b.Status, b.Charge()*100); err != nil {
-			panic(err)
+			return xerrors.Errorf("show battery charge: %w", err)
+		}
+		var details []string
+		if d, ok := b.TimeToEmpty(); ok {
+			details = append(details, formatHoursMinutes(d)+" to empty")
+		}
+		if d, ok := b.TimeToFull(); ok {
+			details = append(details, formatHoursMinutes(d)+" to full")
+		}
+		if w, ok := b.Power(); ok {
+			details = append(details, fmt.Sprintf("%.1f W", w))
+		}
+		if health, ok := b.Health(); ok {
+			details = append(details, fmt.Sprintf("health %.0f%%", health*100))
+		}
+		if b.CycleCount > 0 {
+			details = append(details, fmt.Sprintf("%d cycles", b.CycleCount))
+		}
+		for _, detail := range details {
+			if _, err := fmt.Fprintln(&buf, detail); err != nil {
+				return xerrors.Errorf("show battery charge: %w", err)
+			}
 		}
 	}
-	if err := notify.Send("Battery", buf.String(), 2*time.Second); err != nil {
-		panic(err)
+	slot, err := notify.NewSlot("battery-charge")
+	if err != nil {
+		return xerrors.Errorf("show battery charge: %w", err)
+	}
+	if _, err := slot.Send(&notify.Notification{
+		Summary: summary,
+		Body:    buf.String(),
+		Expire:  2 * time.Second,
+	}); err != nil {
+		return xerrors.Errorf("show battery charge: %w", err)
 	}
+	return nil
+}
+
+func formatHoursMinutes(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
 }
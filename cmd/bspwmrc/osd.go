@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/odsod/bspwmrc/internal/backlight"
+	"github.com/odsod/bspwmrc/internal/notify"
+	"github.com/odsod/bspwmrc/internal/pactl"
+	"golang.org/x/xerrors"
+)
+
+const (
+	volumeStep     = 5
+	brightnessStep = 5
+	osdExpire      = time.Second
+)
+
+func volume(logger *log.Logger, action string) {
+	logger.Printf("volume %s", action)
+	if err := changeVolume(action); err != nil {
+		panic(err)
+	}
+}
+
+func changeVolume(action string) error {
+	sink, err := pactl.DefaultSink()
+	if err != nil {
+		return xerrors.Errorf("change volume: %w", err)
+	}
+	switch action {
+	case "up":
+		err = pactl.SetVolume(stepVolume(sink.Volume, volumeStep))
+	case "down":
+		err = pactl.SetVolume(stepVolume(sink.Volume, -volumeStep))
+	case "mute":
+		err = pactl.ToggleMute()
+	default:
+		return xerrors.Errorf("change volume: unknown action: %s", action)
+	}
+	if err != nil {
+		return xerrors.Errorf("change volume: %w", err)
+	}
+	if sink, err = pactl.DefaultSink(); err != nil {
+		return xerrors.Errorf("change volume: %w", err)
+	}
+	icon := "audio-volume-high"
+	switch {
+	case sink.Muted || sink.Volume == 0:
+		icon = "audio-volume-muted"
+	case sink.Volume < 34:
+		icon = "audio-volume-low"
+	case sink.Volume < 67:
+		icon = "audio-volume-medium"
+	}
+	summary := fmt.Sprintf("Volume %d%%", sink.Volume)
+	if sink.Muted {
+		summary = "Volume muted"
+	}
+	if err := showOSD("volume", icon, summary, sink.Volume); err != nil {
+		return xerrors.Errorf("change volume: %w", err)
+	}
+	return nil
+}
+
+func brightness(logger *log.Logger, action string) {
+	logger.Printf("brightness %s", action)
+	if err := changeBrightness(action); err != nil {
+		panic(err)
+	}
+}
+
+func changeBrightness(action string) error {
+	b, err := backlight.LoadDefault()
+	if err != nil {
+		return xerrors.Errorf("change brightness: %w", err)
+	}
+	switch action {
+	case "up":
+		err = b.Step(brightnessStep)
+	case "down":
+		err = b.Step(-brightnessStep)
+	default:
+		return xerrors.Errorf("change brightness: unknown action: %s", action)
+	}
+	if err != nil {
+		return xerrors.Errorf("change brightness: %w", err)
+	}
+	if err := showOSD("brightness", "display-brightness", fmt.Sprintf("Brightness %d%%", b.Percent()), b.Percent()); err != nil {
+		return xerrors.Errorf("change brightness: %w", err)
+	}
+	return nil
+}
+
+// stepVolume changes volume by delta percent, stopping at 100 when crossing it so that a volume boosted
+// above 100 elsewhere is neither capped nor lowered by stepping up.
+func stepVolume(volume, delta int) int {
+	v := volume + delta
+	switch {
+	case v < 0:
+		return 0
+	case volume <= 100 && v > 100:
+		return 100
+	}
+	return v
+}
+
+// showOSD shows a progress bar notification that replaces the previous one with the same tag.
+func showOSD(tag string, icon string, summary string, percent int) error {
+	slot, err := notify.NewSlot("osd-" + tag)
+	if err != nil {
+		return xerrors.Errorf("show osd: %w", err)
+	}
+	n := &notify.Notification{
+		Summary:  summary,
+		Icon:     icon,
+		Category: "device",
+		Expire:   osdExpire,
+		Hints: map[string]dbus.Variant{
+			"value":                           dbus.MakeVariant(int32(percent)),
+			"x-dunst-stack-tag":               dbus.MakeVariant("bspwmrc-" + tag),
+			"x-canonical-private-synchronous": dbus.MakeVariant("bspwmrc-" + tag),
+		},
+	}
+	n.SetUrgency(notify.UrgencyLow)
+	if _, err := slot.Send(n); err != nil {
+		return xerrors.Errorf("show osd: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/exec"
+
+	"github.com/odsod/bspwmrc/internal/power"
+	"golang.org/x/xerrors"
+)
+
+// powerMonitor runs the configured hooks when power is plugged or unplugged.
+type powerMonitor struct {
+	logger  *log.Logger
+	watcher power.Watcher
+}
+
+func (m *powerMonitor) check(ctx context.Context) error {
+	s, err := power.Load()
+	if err != nil {
+		return xerrors.Errorf("check power: %w", err)
+	}
+	t, ok := m.watcher.Update(s)
+	if !ok {
+		return nil
+	}
+	m.logger.Printf("power %v: %s %.2f%%", t, s.Status, s.Charge()*100)
+	hooks, err := power.LoadHooks()
+	if err != nil {
+		return xerrors.Errorf("check power: %w", err)
+	}
+	var firstErr error
+	for _, cmd := range hooks.For(t) {
+		m.logger.Printf("power %v hook: %v", t, cmd)
+		if err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).Run(); err != nil && firstErr == nil {
+			firstErr = xerrors.Errorf("check power: %v hook %v: %w", t, cmd, err)
+		}
+	}
+	return firstErr
+}
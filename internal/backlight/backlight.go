@@ -0,0 +1,149 @@
+package backlight
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/godbus/dbus"
+	"github.com/odsod/bspwmrc/internal/sysfs"
+	"golang.org/x/xerrors"
+)
+
+const backlightDirname = "/sys/class/backlight"
+
+type B struct {
+	Name          string
+	Dir           string
+	Brightness    int
+	MaxBrightness int
+}
+
+func (b *B) Percent() int {
+	if b.MaxBrightness == 0 {
+		return 0
+	}
+	return (b.Brightness*100 + b.MaxBrightness/2) / b.MaxBrightness
+}
+
+// Step changes the brightness by delta percent of the maximum, but always by at least one raw unit.
+func (b *B) Step(delta int) error {
+	magnitude := delta
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	step := (magnitude*b.MaxBrightness + 50) / 100
+	if step < 1 {
+		step = 1
+	}
+	if delta < 0 {
+		step = -step
+	}
+	return b.set(b.Brightness + step)
+}
+
+// set writes the brightness, keeping the backlight on at the bottom of the range.
+func (b *B) set(brightness int) error {
+	if brightness < 1 {
+		brightness = 1
+	}
+	if brightness > b.MaxBrightness {
+		brightness = b.MaxBrightness
+	}
+	f := filepath.Join(b.Dir, "brightness")
+	err := ioutil.WriteFile(f, []byte(strconv.Itoa(brightness)), 0644)
+	if os.IsPermission(err) {
+		// Unprivileged users without a udev rule for the device can still set it through their logind session.
+		if logindErr := setLogind(b.Name, brightness); logindErr != nil {
+			return xerrors.Errorf("set brightness %s: no permission to write %s and logind failed: %w", b.Name, f, logindErr)
+		}
+		err = nil
+	}
+	if err != nil {
+		return xerrors.Errorf("set brightness %s: %w", b.Name, err)
+	}
+	b.Brightness = brightness
+	return nil
+}
+
+func setLogind(name string, brightness int) error {
+	conn, err := dbus.SystemBusPrivate()
+	if err != nil {
+		return xerrors.Errorf("set brightness with logind: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.Auth(nil); err != nil {
+		return xerrors.Errorf("set brightness with logind: %w", err)
+	}
+	if err := conn.Hello(); err != nil {
+		return xerrors.Errorf("set brightness with logind: %w", err)
+	}
+	session := conn.Object("org.freedesktop.login1", "/org/freedesktop/login1/session/auto")
+	if err := session.Call("org.freedesktop.login1.Session.SetBrightness", 0, "backlight", name, uint32(brightness)).Err; err != nil {
+		return xerrors.Errorf("set brightness with logind: %w", err)
+	}
+	return nil
+}
+
+// typePriority orders backlight types as recommended by the kernel: firmware and platform interfaces
+// know the panel, while raw interfaces poke the hardware directly.
+var typePriority = map[string]int{
+	"firmware": 0,
+	"platform": 1,
+	"raw":      2,
+}
+
+func readType(dir string) string {
+	t, err := sysfs.ReadStr(filepath.Join(dir, "type"))
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+func priority(dir string) int {
+	if p, ok := typePriority[readType(dir)]; ok {
+		return p
+	}
+	return len(typePriority)
+}
+
+func Load(dir string) (*B, error) {
+	brightness, err := sysfs.ReadInt(filepath.Join(dir, "brightness"))
+	if err != nil {
+		return nil, xerrors.Errorf("load backlight: %w", err)
+	}
+	maxBrightness, err := sysfs.ReadInt(filepath.Join(dir, "max_brightness"))
+	if err != nil {
+		return nil, xerrors.Errorf("load backlight: %w", err)
+	}
+	return &B{
+		Name:          filepath.Base(dir),
+		Dir:           dir,
+		Brightness:    brightness,
+		MaxBrightness: maxBrightness,
+	}, nil
+}
+
+// LoadDefault loads the backlight device with the preferred type, the first one by name on ties.
+func LoadDefault() (*B, error) {
+	fis, err := ioutil.ReadDir(backlightDirname)
+	if err != nil {
+		return nil, xerrors.Errorf("load default backlight: %w", err)
+	}
+	if len(fis) == 0 {
+		return nil, xerrors.New("load default backlight: no backlight devices")
+	}
+	dir := filepath.Join(backlightDirname, fis[0].Name())
+	for _, fi := range fis[1:] {
+		if candidate := filepath.Join(backlightDirname, fi.Name()); priority(candidate) < priority(dir) {
+			dir = candidate
+		}
+	}
+	b, err := Load(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("load default backlight: %w", err)
+	}
+	return b, nil
+}
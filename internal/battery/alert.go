@@ -0,0 +1,66 @@
+package battery
+
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelWarning
+	LevelCritical
+	LevelAction
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelWarning:
+		return "warning"
+	case LevelCritical:
+		return "critical"
+	case LevelAction:
+		return "action"
+	}
+	return "none"
+}
+
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+	Action   float64
+}
+
+func (t *Thresholds) Level(charge float64) Level {
+	switch {
+	case charge <= t.Action:
+		return LevelAction
+	case charge <= t.Critical:
+		return LevelCritical
+	case charge <= t.Warning:
+		return LevelWarning
+	}
+	return LevelNone
+}
+
+// Alerter tracks which levels have been alerted during the current discharge cycle of the system.
+type Alerter struct {
+	Thresholds Thresholds
+	alerted    Level
+}
+
+// Update returns the level to alert for the aggregate charge of all system batteries, or LevelNone when
+// that level or a higher one has already been alerted since the system last started charging. Only a
+// system that is discharging and not on mains is alerted, since batteries on mains may report
+// "Not charging" or "Unknown" at any charge.
+func (a *Alerter) Update(charge float64, status string, onMains bool) Level {
+	if onMains || status == "Charging" || status == "Full" {
+		a.alerted = LevelNone
+		return LevelNone
+	}
+	if status != "Discharging" {
+		return LevelNone
+	}
+	level := a.Thresholds.Level(charge)
+	if level <= a.alerted {
+		return LevelNone
+	}
+	a.alerted = level
+	return level
+}
@@ -1,93 +1,176 @@
 package battery
 
 import (
-	"bytes"
 	"io/ioutil"
 	"path"
 	"path/filepath"
-	"strconv"
+	"time"
 
+	"github.com/odsod/bspwmrc/internal/sysfs"
 	"golang.org/x/xerrors"
 )
 
 const powerSupplyDirname = "/sys/class/power_supply"
 
+// B is a battery. Energy is in µWh, power in µW, charge in µAh, current in µA and voltage in µV,
+// as reported by the kernel. Drivers report either the energy or the charge attributes.
 type B struct {
-	Name       string
-	Status     string
-	EnergyNow  int
-	EnergyFull int
+	Name             string
+	Status           string
+	EnergyNow        int
+	EnergyFull       int
+	EnergyFullDesign int
+	PowerNow         int
+	ChargeNow        int
+	ChargeFull       int
+	ChargeFullDesign int
+	CurrentNow       int
+	VoltageNow       int
+	CycleCount       int
+	// Capacity is the charge in percent as computed by the driver.
+	Capacity int
 }
 
 func (b *B) Charge() float64 {
-	if b.EnergyFull == 0 {
-		return 0
+	switch {
+	case b.EnergyFull > 0:
+		return float64(b.EnergyNow) / float64(b.EnergyFull)
+	case b.ChargeFull > 0:
+		return float64(b.ChargeNow) / float64(b.ChargeFull)
+	}
+	return float64(b.Capacity) / 100
+}
+
+// TimeToEmpty estimates the time until b is empty at the current rate of discharge.
+func (b *B) TimeToEmpty() (time.Duration, bool) {
+	if b.Status != "Discharging" {
+		return 0, false
+	}
+	return b.hoursAtRate(b.EnergyNow, b.ChargeNow)
+}
+
+// TimeToFull estimates the time until b is full at the current rate of charge.
+func (b *B) TimeToFull() (time.Duration, bool) {
+	if b.Status != "Charging" {
+		return 0, false
+	}
+	return b.hoursAtRate(b.EnergyFull-b.EnergyNow, b.ChargeFull-b.ChargeNow)
+}
+
+func (b *B) hoursAtRate(energy int, charge int) (time.Duration, bool) {
+	var hours float64
+	switch {
+	case b.EnergyFull > 0 && b.PowerNow != 0:
+		hours = float64(energy) / float64(abs(b.PowerNow))
+	case b.ChargeFull > 0 && b.CurrentNow != 0:
+		hours = float64(charge) / float64(abs(b.CurrentNow))
+	default:
+		return 0, false
+	}
+	return time.Duration(hours * float64(time.Hour)), true
+}
+
+// Health is the full capacity of b relative to its design capacity.
+func (b *B) Health() (float64, bool) {
+	switch {
+	case b.EnergyFullDesign > 0:
+		return float64(b.EnergyFull) / float64(b.EnergyFullDesign), true
+	case b.ChargeFullDesign > 0:
+		return float64(b.ChargeFull) / float64(b.ChargeFullDesign), true
+	}
+	return 0, false
+}
+
+// Power is the current rate of charge or discharge in watts.
+func (b *B) Power() (float64, bool) {
+	switch {
+	case b.PowerNow != 0:
+		return float64(abs(b.PowerNow)) / 1e6, true
+	case b.CurrentNow != 0 && b.VoltageNow != 0:
+		return float64(abs(b.CurrentNow)) / 1e6 * float64(b.VoltageNow) / 1e6, true
 	}
-	return float64(b.EnergyNow) / float64(b.EnergyFull)
+	return 0, false
 }
 
-func listBatteries() ([]string, error) {
+func abs(i int) int {
+	// Some drivers report a negative rate while discharging.
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// ListSupplies returns the power supplies of type t that power the system. Supplies with device scope,
+// such as the battery of a wireless mouse, are skipped.
+func ListSupplies(t string) ([]string, error) {
 	filenames, err := ioutil.ReadDir(powerSupplyDirname)
 	if err != nil {
-		return nil, xerrors.Errorf("list batteries: %w", err)
+		return nil, xerrors.Errorf("list supplies: %w", err)
 	}
 	result := make([]string, 0, len(filenames))
 	for _, filename := range filenames {
 		powerSupply := filepath.Join(powerSupplyDirname, filename.Name())
-		t, err := ioutil.ReadFile(filepath.Join(powerSupply, "type"))
+		supplyType, err := sysfs.ReadStr(filepath.Join(powerSupply, "type"))
 		if err != nil {
-			return nil, xerrors.Errorf("list batteries: %w", err)
+			return nil, xerrors.Errorf("list supplies: %w", err)
+		}
+		if supplyType != t {
+			continue
 		}
-		if bytes.Equal(t, []byte("Battery\n")) {
-			result = append(result, powerSupply)
+		// The scope attribute is optional, a missing one means system scope.
+		if scope, err := sysfs.ReadStr(filepath.Join(powerSupply, "scope")); err == nil && scope == "Device" {
+			continue
 		}
+		result = append(result, powerSupply)
 	}
 	return result, nil
 }
 
-func readStr(f string) (string, error) {
-	data, err := ioutil.ReadFile(f)
-	if err != nil {
-		return "", xerrors.Errorf("read str: %w", err)
-	}
-	return string(bytes.TrimSpace(data)), nil
-}
-
-func readInt(f string) (int, error) {
-	data, err := ioutil.ReadFile(f)
+// readOptionalInt returns zero when the attribute is not exposed by the driver. Some drivers expose
+// attributes that fail to read, e.g. with ENODATA or ENODEV, which are treated as absent too.
+func readOptionalInt(f string) int {
+	i, err := sysfs.ReadInt(f)
 	if err != nil {
-		return 0, xerrors.Errorf("read int: %w", err)
-	}
-	i, err := strconv.Atoi(string(bytes.TrimSpace(data)))
-	if err != nil {
-		return 0, xerrors.Errorf("read int: %w", err)
+		return 0
 	}
-	return i, nil
+	return i
 }
 
 func Load(f string) (*B, error) {
-	status, err := readStr(filepath.Join(f, "status"))
+	status, err := sysfs.ReadStr(filepath.Join(f, "status"))
 	if err != nil {
 		return nil, xerrors.Errorf("load battery: %w", err)
 	}
-	energyFull, err := readInt(filepath.Join(f, "energy_full"))
-	if err != nil {
-		return nil, xerrors.Errorf("load battery: %w", err)
+	b := &B{
+		Name:   path.Base(f),
+		Status: status,
 	}
-	energyNow, err := readInt(filepath.Join(f, "energy_now"))
-	if err != nil {
-		return nil, xerrors.Errorf("load battery: %w", err)
+	for _, attr := range []struct {
+		name  string
+		value *int
+	}{
+		{name: "energy_now", value: &b.EnergyNow},
+		{name: "energy_full", value: &b.EnergyFull},
+		{name: "energy_full_design", value: &b.EnergyFullDesign},
+		{name: "power_now", value: &b.PowerNow},
+		{name: "charge_now", value: &b.ChargeNow},
+		{name: "charge_full", value: &b.ChargeFull},
+		{name: "charge_full_design", value: &b.ChargeFullDesign},
+		{name: "current_now", value: &b.CurrentNow},
+		{name: "voltage_now", value: &b.VoltageNow},
+		{name: "cycle_count", value: &b.CycleCount},
+		{name: "capacity", value: &b.Capacity},
+	} {
+		*attr.value = readOptionalInt(filepath.Join(f, attr.name))
+	}
+	if b.EnergyFull == 0 && b.ChargeFull == 0 && b.Capacity == 0 {
+		return nil, xerrors.Errorf("load battery %s: no energy, charge or capacity attributes", b.Name)
 	}
-	return &B{
-		Name:       path.Base(f),
-		Status:     status,
-		EnergyFull: energyFull,
-		EnergyNow:  energyNow,
-	}, nil
+	return b, nil
 }
 
 func LoadAll() ([]*B, error) {
-	bs, err := listBatteries()
+	bs, err := ListSupplies("Battery")
 	if err != nil {
 		return nil, xerrors.Errorf("load all batteries: %w", err)
 	}
@@ -0,0 +1,96 @@
+package battery
+
+import (
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const configFilename = "battery.json"
+
+type Config struct {
+	Thresholds Thresholds
+	// Command runs when a battery reaches the action threshold.
+	Command []string
+	// Countdown is the time to cancel the command after the action notification.
+	Countdown time.Duration
+}
+
+type configFile struct {
+	Warning   *float64 `json:"warning"`
+	Critical  *float64 `json:"critical"`
+	Action    *float64 `json:"action"`
+	Command   []string `json:"command"`
+	Countdown string   `json:"countdown"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Thresholds: Thresholds{
+			Warning:  0.15,
+			Critical: 0.07,
+			Action:   0.03,
+		},
+		Command:   []string{"systemctl", "suspend"},
+		Countdown: time.Minute,
+	}
+}
+
+func ConfigPath() (string, error) {
+	return xdg.ConfigPath(configFilename)
+}
+
+// LoadConfig returns the default config overridden by the fields set in the config file.
+func LoadConfig() (*Config, error) {
+	cf, err := xdg.ReadConfig(configFilename)
+	if err != nil {
+		return nil, xerrors.Errorf("load battery config: %w", err)
+	}
+	if cf == nil {
+		return DefaultConfig(), nil
+	}
+	var f configFile
+	if err := cf.Decode(&f); err != nil {
+		return nil, xerrors.Errorf("load battery config: %w", err)
+	}
+	config := DefaultConfig()
+	if f.Warning != nil {
+		config.Thresholds.Warning = *f.Warning
+	}
+	if f.Critical != nil {
+		config.Thresholds.Critical = *f.Critical
+	}
+	if f.Action != nil {
+		config.Thresholds.Action = *f.Action
+	}
+	if f.Command != nil {
+		config.Command = f.Command
+	}
+	if f.Countdown != "" {
+		if config.Countdown, err = time.ParseDuration(f.Countdown); err != nil {
+			return nil, xerrors.Errorf("parse %s: countdown: %w", cf.Path, err)
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, xerrors.Errorf("parse %s: %w", cf.Path, err)
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	t := c.Thresholds
+	switch {
+	case t.Warning <= 0 || t.Warning >= 1:
+		return xerrors.Errorf("warning: must be a fraction in (0, 1), got %v", t.Warning)
+	case t.Critical <= 0 || t.Critical > t.Warning:
+		return xerrors.Errorf("critical: must be in (0, warning], got %v", t.Critical)
+	case t.Action < 0 || t.Action > t.Critical:
+		return xerrors.Errorf("action: must be in [0, critical], got %v", t.Action)
+	case len(c.Command) == 0 || c.Command[0] == "":
+		return xerrors.New("command: must not be empty")
+	case c.Countdown < 0:
+		return xerrors.Errorf("countdown: must not be negative, got %v", c.Countdown)
+	}
+	return nil
+}
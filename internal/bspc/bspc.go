@@ -1,15 +1,37 @@
 package bspc
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"strings"
+	"time"
 
 	"golang.org/x/xerrors"
 )
 
+const (
+	failureMarker      = "\x07"
+	defaultDialTimeout = 2 * time.Second
+	defaultReadTimeout = 5 * time.Second
+)
+
+type Error struct {
+	Args    []string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bspc %s: %s", strings.Join(e.Args, " "), e.Message)
+}
+
+func encodeMessage(args []string) []byte {
+	return []byte(strings.Join(args, "\x00") + "\x00")
+}
+
 func resolveSocketPath() (string, error) {
 	if socketPathFromEnv, ok := os.LookupEnv("BSPWM_SOCKET"); ok {
 		return socketPathFromEnv, nil
@@ -29,27 +51,113 @@ func resolveSocketPath() (string, error) {
 	return fmt.Sprintf("/tmp/bspwm%s_%s_%s-socket", host, display, screen), nil
 }
 
-func Run(args ...string) (response []byte, err error) {
-	socketPath, err := resolveSocketPath()
-	if err != nil {
-		return nil, xerrors.Errorf("bspc run: %w", err)
+type Client struct {
+	socketPath  string
+	dialTimeout time.Duration
+	readTimeout time.Duration
+}
+
+type Option func(*Client)
+
+func WithSocketPath(socketPath string) Option {
+	return func(c *Client) {
+		c.socketPath = socketPath
+	}
+}
+
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.dialTimeout = timeout
+	}
+}
+
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.readTimeout = timeout
+	}
+}
+
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{
+		dialTimeout: defaultDialTimeout,
+		readTimeout: defaultReadTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.socketPath == "" {
+		socketPath, err := resolveSocketPath()
+		if err != nil {
+			return nil, xerrors.Errorf("new bspc client: %w", err)
+		}
+		c.socketPath = socketPath
 	}
-	socket, err := net.Dial("unix", socketPath)
+	return c, nil
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.dialTimeout}
+	return dialer.DialContext(ctx, "unix", c.socketPath)
+}
+
+func (c *Client) Run(ctx context.Context, args ...string) (response []byte, err error) {
+	socket, err := c.dial(ctx)
 	if err != nil {
 		return nil, xerrors.Errorf("bspc run: %w", err)
 	}
 	defer func() {
-		if errClose := socket.Close(); err != nil {
+		if errClose := socket.Close(); errClose != nil && err == nil {
 			response, err = nil, xerrors.Errorf("bspc run: %w", errClose)
 		}
 	}()
-	_, err = socket.Write([]byte(strings.Join(args, "\x00") + "\x00"))
-	if err != nil {
-		return nil, xerrors.Errorf("bspc run: %w", err)
+	if c.readTimeout > 0 {
+		if err := socket.SetDeadline(time.Now().Add(c.readTimeout)); err != nil {
+			return nil, xerrors.Errorf("bspc run: %w", err)
+		}
+	}
+	stop := interruptOnDone(ctx, socket)
+	defer stop()
+	if _, err := socket.Write(encodeMessage(args)); err != nil {
+		return nil, xerrors.Errorf("bspc run: %w", contextErr(ctx, err))
 	}
 	data, err := ioutil.ReadAll(socket)
 	if err != nil {
-		return nil, xerrors.Errorf("bspc run: %w", err)
+		return nil, xerrors.Errorf("bspc run: %w", contextErr(ctx, err))
+	}
+	if bytes.HasPrefix(data, []byte(failureMarker)) {
+		return nil, &Error{
+			Args:    args,
+			Message: string(bytes.TrimSpace(data[len(failureMarker):])),
+		}
 	}
 	return data, nil
 }
+
+func Run(args ...string) ([]byte, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("bspc run: %w", err)
+	}
+	return c.Run(context.Background(), args...)
+}
+
+func interruptOnDone(ctx context.Context, socket net.Conn) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = socket.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+func contextErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
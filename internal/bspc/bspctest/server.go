@@ -0,0 +1,249 @@
+package bspctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const socketEnv = "BSPWM_SOCKET"
+
+type Server struct {
+	SocketPath string
+
+	dir         string
+	listener    net.Listener
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	commands    [][]string
+	handlers    []HandlerFunc
+	subscribers []*subscriber
+	subscribed  chan struct{}
+}
+
+type HandlerFunc func(args []string) (response string, ok bool)
+
+type subscriber struct {
+	conn   net.Conn
+	events []string
+}
+
+func NewServer() *Server {
+	dir, err := ioutil.TempDir("", "bspctest")
+	if err != nil {
+		panic(fmt.Sprintf("bspctest: %v", err))
+	}
+	socketPath := filepath.Join(dir, "bspwm-socket")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		panic(fmt.Sprintf("bspctest: %v", err))
+	}
+	s := &Server{
+		SocketPath: socketPath,
+		dir:        dir,
+		listener:   listener,
+		subscribed: make(chan struct{}, 1),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for _, sub := range s.subscribers {
+		_ = sub.conn.Close()
+	}
+	s.subscribers = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+	_ = os.RemoveAll(s.dir)
+}
+
+func (s *Server) Setenv() (restore func()) {
+	prev, hadPrev := os.LookupEnv(socketEnv)
+	if err := os.Setenv(socketEnv, s.SocketPath); err != nil {
+		panic(fmt.Sprintf("bspctest: %v", err))
+	}
+	return func() {
+		if hadPrev {
+			_ = os.Setenv(socketEnv, prev)
+		} else {
+			_ = os.Unsetenv(socketEnv)
+		}
+	}
+}
+
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([][]string, len(s.commands))
+	copy(result, s.commands)
+	return result
+}
+
+func (s *Server) Handle(h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, h)
+}
+
+func (s *Server) Reply(args []string, response string) {
+	s.Handle(func(received []string) (string, bool) {
+		return response, reflect.DeepEqual(received, args)
+	})
+}
+
+func (s *Server) ReplyFailure(args []string, message string) {
+	s.Reply(args, "\x07"+message+"\n")
+}
+
+func (s *Server) ReplyJSON(args []string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("bspctest: %v", err))
+	}
+	s.Reply(args, string(data)+"\n")
+}
+
+func (s *Server) ReplyState(v interface{}) {
+	s.ReplyJSON([]string{"wm", "-d"}, v)
+}
+
+func (s *Server) WaitSubscriber(ctx context.Context) error {
+	select {
+	case <-s.subscribed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) Emit(line string) int {
+	name := eventName(line)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for _, sub := range s.subscribers {
+		if !sub.wants(name) {
+			continue
+		}
+		if err := sub.conn.SetWriteDeadline(time.Now().Add(time.Second)); err != nil {
+			continue
+		}
+		if _, err := sub.conn.Write([]byte(line + "\n")); err != nil {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	args, err := readMessage(conn)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.commands = append(s.commands, args)
+	if len(args) > 0 && args[0] == "subscribe" {
+		s.subscribers = append(s.subscribers, &subscriber{conn: conn, events: subscribedEvents(args[1:])})
+		s.mu.Unlock()
+		select {
+		case s.subscribed <- struct{}{}:
+		default:
+		}
+		return
+	}
+	var response string
+	for i := len(s.handlers) - 1; i >= 0; i-- {
+		if r, ok := s.handlers[i](args); ok {
+			response = r
+			break
+		}
+	}
+	s.mu.Unlock()
+	_, _ = conn.Write([]byte(response))
+	_ = conn.Close()
+}
+
+func readMessage(conn net.Conn) ([]string, error) {
+	var buf bytes.Buffer
+	chunk := make([]byte, 4096)
+	for {
+		n, err := conn.Read(chunk)
+		buf.Write(chunk[:n])
+		if bytes.HasSuffix(buf.Bytes(), []byte{0}) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\x00"), "\x00"), nil
+}
+
+func subscribedEvents(args []string) []string {
+	var events []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c" || args[i] == "--count":
+			i++
+			continue
+		case strings.HasPrefix(args[i], "-"):
+			// Flags such as --fifo take no value.
+			continue
+		}
+		events = append(events, args[i])
+	}
+	if len(events) == 0 {
+		events = []string{"report"}
+	}
+	return events
+}
+
+func (sub *subscriber) wants(name string) bool {
+	for _, e := range sub.events {
+		if e == "all" || e == name {
+			return true
+		}
+	}
+	return false
+}
+
+func eventName(line string) string {
+	if strings.HasPrefix(line, "W") {
+		return "report"
+	}
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
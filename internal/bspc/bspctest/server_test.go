@@ -0,0 +1,62 @@
+package bspctest
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSubscribedEvents(t *testing.T) {
+	for _, tt := range []struct {
+		args     []string
+		expected []string
+	}{
+		{args: nil, expected: []string{"report"}},
+		{args: []string{"node_add", "node_remove"}, expected: []string{"node_add", "node_remove"}},
+		{args: []string{"--fifo", "node_add"}, expected: []string{"node_add"}},
+		{args: []string{"-f", "node_add"}, expected: []string{"node_add"}},
+		{args: []string{"--count", "1", "node_add"}, expected: []string{"node_add"}},
+		{args: []string{"-c", "2", "--fifo"}, expected: []string{"report"}},
+	} {
+		if actual := subscribedEvents(tt.args); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("subscribedEvents(%q) = %q, expected %q", tt.args, actual, tt.expected)
+		}
+	}
+}
+
+func TestServer_Emit(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	conn, err := net.Dial("unix", s.SocketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("subscribe\x00--fifo\x00node_add\x00")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.WaitSubscriber(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Emit("node_remove 0x1 0x2 0x3"); n != 0 {
+		t.Errorf("emitted node_remove to %d subscribers, expected 0", n)
+	}
+	if n := s.Emit("node_add 0x1 0x2 0x0 0x3"); n != 1 {
+		t.Errorf("emitted node_add to %d subscribers, expected 1", n)
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(buf[:n]), "node_add 0x1 0x2 0x0 0x3\n"; actual != expected {
+		t.Errorf("received %q, expected %q", actual, expected)
+	}
+}
@@ -0,0 +1,531 @@
+package bspc
+
+import (
+	"context"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+type Command interface {
+	Args() ([]string, error)
+}
+
+type Flag string
+
+const (
+	Hidden  Flag = "hidden"
+	Sticky  Flag = "sticky"
+	Private Flag = "private"
+	Locked  Flag = "locked"
+	Marked  Flag = "marked"
+)
+
+type State string
+
+const (
+	StateTiled       State = "tiled"
+	StatePseudoTiled State = "pseudo_tiled"
+	StateFloating    State = "floating"
+	StateFullscreen  State = "fullscreen"
+)
+
+type Layer string
+
+const (
+	LayerBelow  Layer = "below"
+	LayerNormal Layer = "normal"
+	LayerAbove  Layer = "above"
+)
+
+type Layout string
+
+const (
+	Tiled      Layout = "tiled"
+	Monocle    Layout = "monocle"
+	NextLayout Layout = "next"
+	PrevLayout Layout = "prev"
+)
+
+type Direction string
+
+const (
+	DirNorth Direction = "north"
+	DirWest  Direction = "west"
+	DirSouth Direction = "south"
+	DirEast  Direction = "east"
+)
+
+type Handle string
+
+const (
+	HandleTop         Handle = "top"
+	HandleLeft        Handle = "left"
+	HandleBottom      Handle = "bottom"
+	HandleRight       Handle = "right"
+	HandleTopLeft     Handle = "top_left"
+	HandleTopRight    Handle = "top_right"
+	HandleBottomRight Handle = "bottom_right"
+	HandleBottomLeft  Handle = "bottom_left"
+)
+
+type builder struct {
+	domain     string
+	args       []string
+	err        error
+	used       map[string]bool
+	terminated string
+	selector   *selector
+}
+
+func newBuilder(domain string, sels []selector) builder {
+	b := builder{domain: domain, used: make(map[string]bool)}
+	switch len(sels) {
+	case 0:
+	case 1:
+		b.selector = &sels[0]
+	default:
+		b.fail("more than one selector")
+	}
+	return b
+}
+
+func (b *builder) fail(format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = xerrors.Errorf(b.domain+": "+format, args...)
+	}
+}
+
+func (b *builder) add(option string, args ...string) {
+	if b.err != nil {
+		return
+	}
+	if b.terminated != "" {
+		b.fail("%s after %s", option, b.terminated)
+		return
+	}
+	b.args = append(b.args, option)
+	b.args = append(b.args, args...)
+}
+
+func (b *builder) addOnce(option string, args ...string) {
+	if b.used[option] {
+		b.fail("duplicate %s", option)
+		return
+	}
+	b.used[option] = true
+	b.add(option, args...)
+}
+
+func (b *builder) addSelector(option string, sels []selector) {
+	switch len(sels) {
+	case 0:
+		b.addOnce(option)
+	case 1:
+		sel, err := commandSelector(sels[0])
+		if err != nil {
+			b.fail("%s: %v", option, err)
+			return
+		}
+		b.addOnce(option, sel)
+	default:
+		b.fail("%s: more than one selector", option)
+	}
+}
+
+func (b *builder) terminate(option string) {
+	b.addOnce(option)
+	if b.err == nil {
+		b.terminated = option
+	}
+}
+
+func (b *builder) build() ([]string, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	result := []string{b.domain}
+	if b.selector != nil {
+		sel, err := commandSelector(*b.selector)
+		if err != nil {
+			return nil, xerrors.Errorf("%s: %w", b.domain, err)
+		}
+		result = append(result, sel)
+	}
+	if len(b.args) == 0 {
+		return nil, xerrors.Errorf("%s: no commands", b.domain)
+	}
+	return append(result, b.args...), nil
+}
+
+type NodeCommand struct {
+	b builder
+}
+
+func Node(sel ...NodeSelector) *NodeCommand {
+	return &NodeCommand{b: newBuilder("node", nodeSelectors(sel))}
+}
+
+func (c *NodeCommand) Focus(sel ...NodeSelector) *NodeCommand {
+	c.b.addSelector("--focus", nodeSelectors(sel))
+	return c
+}
+
+func (c *NodeCommand) Activate(sel ...NodeSelector) *NodeCommand {
+	c.b.addSelector("--activate", nodeSelectors(sel))
+	return c
+}
+
+func (c *NodeCommand) ToDesktop(d DesktopSelector) *NodeCommand {
+	c.b.addSelector("--to-desktop", []selector{d.selector})
+	return c
+}
+
+func (c *NodeCommand) ToMonitor(m MonitorSelector) *NodeCommand {
+	c.b.addSelector("--to-monitor", []selector{m.selector})
+	return c
+}
+
+func (c *NodeCommand) ToNode(n NodeSelector) *NodeCommand {
+	c.b.addSelector("--to-node", []selector{n.selector})
+	return c
+}
+
+func (c *NodeCommand) Swap(n NodeSelector) *NodeCommand {
+	c.b.addSelector("--swap", []selector{n.selector})
+	return c
+}
+
+func (c *NodeCommand) Follow() *NodeCommand {
+	if !c.b.used["--to-desktop"] && !c.b.used["--to-monitor"] && !c.b.used["--to-node"] && !c.b.used["--swap"] {
+		c.b.fail("--follow without --to-desktop, --to-monitor, --to-node or --swap")
+		return c
+	}
+	c.b.addOnce("--follow")
+	return c
+}
+
+func (c *NodeCommand) PreselDir(d Direction) *NodeCommand {
+	switch d {
+	case DirNorth, DirWest, DirSouth, DirEast:
+		c.b.addOnce("--presel-dir", string(d))
+	default:
+		c.b.fail("invalid direction: %s", d)
+	}
+	return c
+}
+
+func (c *NodeCommand) CancelPresel() *NodeCommand {
+	c.b.addOnce("--presel-dir", "cancel")
+	return c
+}
+
+func (c *NodeCommand) PreselRatio(r float64) *NodeCommand {
+	if r <= 0 || r >= 1 {
+		c.b.fail("invalid presel ratio: %v", r)
+		return c
+	}
+	c.b.addOnce("--presel-ratio", formatRatio(r))
+	return c
+}
+
+func (c *NodeCommand) Ratio(r float64) *NodeCommand {
+	if r <= 0 || r >= 1 {
+		c.b.fail("invalid ratio: %v", r)
+		return c
+	}
+	c.b.addOnce("--ratio", formatRatio(r))
+	return c
+}
+
+func (c *NodeCommand) Move(dx, dy int) *NodeCommand {
+	c.b.add("--move", strconv.Itoa(dx), strconv.Itoa(dy))
+	return c
+}
+
+func (c *NodeCommand) Resize(h Handle, dx, dy int) *NodeCommand {
+	switch h {
+	case HandleTop, HandleLeft, HandleBottom, HandleRight,
+		HandleTopLeft, HandleTopRight, HandleBottomRight, HandleBottomLeft:
+		c.b.add("--resize", string(h), strconv.Itoa(dx), strconv.Itoa(dy))
+	default:
+		c.b.fail("invalid resize handle: %s", h)
+	}
+	return c
+}
+
+func (c *NodeCommand) State(s State) *NodeCommand {
+	switch s {
+	case StateTiled, StatePseudoTiled, StateFloating, StateFullscreen:
+		c.b.addOnce("--state", string(s))
+	default:
+		c.b.fail("invalid state: %s", s)
+	}
+	return c
+}
+
+func (c *NodeCommand) Layer(l Layer) *NodeCommand {
+	switch l {
+	case LayerBelow, LayerNormal, LayerAbove:
+		c.b.addOnce("--layer", string(l))
+	default:
+		c.b.fail("invalid layer: %s", l)
+	}
+	return c
+}
+
+func (c *NodeCommand) Flag(f Flag, on bool) *NodeCommand {
+	switch f {
+	case Hidden, Sticky, Private, Locked, Marked:
+	default:
+		c.b.fail("invalid flag: %s", f)
+		return c
+	}
+	if c.b.used["--flag "+string(f)] {
+		c.b.fail("duplicate --flag %s", f)
+		return c
+	}
+	c.b.used["--flag "+string(f)] = true
+	c.b.add("--flag", string(f)+"="+onOff(on))
+	return c
+}
+
+func (c *NodeCommand) InsertReceptacle() *NodeCommand {
+	c.b.addOnce("--insert-receptacle")
+	return c
+}
+
+func (c *NodeCommand) Close() *NodeCommand {
+	c.b.terminate("--close")
+	return c
+}
+
+func (c *NodeCommand) Kill() *NodeCommand {
+	c.b.terminate("--kill")
+	return c
+}
+
+func (c *NodeCommand) Args() ([]string, error) {
+	return c.b.build()
+}
+
+type DesktopCommand struct {
+	b builder
+}
+
+func Desktop(sel ...DesktopSelector) *DesktopCommand {
+	return &DesktopCommand{b: newBuilder("desktop", desktopSelectors(sel))}
+}
+
+func (c *DesktopCommand) Focus(sel ...DesktopSelector) *DesktopCommand {
+	c.b.addSelector("--focus", desktopSelectors(sel))
+	return c
+}
+
+func (c *DesktopCommand) Activate(sel ...DesktopSelector) *DesktopCommand {
+	c.b.addSelector("--activate", desktopSelectors(sel))
+	return c
+}
+
+func (c *DesktopCommand) ToMonitor(m MonitorSelector) *DesktopCommand {
+	c.b.addSelector("--to-monitor", []selector{m.selector})
+	return c
+}
+
+func (c *DesktopCommand) Swap(d DesktopSelector) *DesktopCommand {
+	c.b.addSelector("--swap", []selector{d.selector})
+	return c
+}
+
+func (c *DesktopCommand) Layout(l Layout) *DesktopCommand {
+	switch l {
+	case Tiled, Monocle, NextLayout, PrevLayout:
+		c.b.addOnce("--layout", string(l))
+	default:
+		c.b.fail("invalid layout: %s", l)
+	}
+	return c
+}
+
+func (c *DesktopCommand) Rename(name string) *DesktopCommand {
+	if name == "" {
+		c.b.fail("empty name")
+		return c
+	}
+	c.b.addOnce("--rename", name)
+	return c
+}
+
+func (c *DesktopCommand) Remove() *DesktopCommand {
+	c.b.terminate("--remove")
+	return c
+}
+
+func (c *DesktopCommand) Args() ([]string, error) {
+	return c.b.build()
+}
+
+type MonitorCommand struct {
+	b builder
+}
+
+func Monitor(sel ...MonitorSelector) *MonitorCommand {
+	return &MonitorCommand{b: newBuilder("monitor", monitorSelectors(sel))}
+}
+
+func (c *MonitorCommand) Focus(sel ...MonitorSelector) *MonitorCommand {
+	c.b.addSelector("--focus", monitorSelectors(sel))
+	return c
+}
+
+func (c *MonitorCommand) Swap(m MonitorSelector) *MonitorCommand {
+	c.b.addSelector("--swap", []selector{m.selector})
+	return c
+}
+
+func (c *MonitorCommand) AddDesktops(names ...string) *MonitorCommand {
+	if len(names) == 0 {
+		c.b.fail("--add-desktops: no names")
+		return c
+	}
+	for _, name := range names {
+		if name == "" {
+			c.b.fail("--add-desktops: empty name")
+			return c
+		}
+	}
+	c.b.add("--add-desktops", names...)
+	return c
+}
+
+func (c *MonitorCommand) ResetDesktops(names ...string) *MonitorCommand {
+	if len(names) == 0 {
+		c.b.fail("--reset-desktops: no names")
+		return c
+	}
+	c.b.addOnce("--reset-desktops", names...)
+	return c
+}
+
+func (c *MonitorCommand) Rename(name string) *MonitorCommand {
+	if name == "" {
+		c.b.fail("empty name")
+		return c
+	}
+	c.b.addOnce("--rename", name)
+	return c
+}
+
+func (c *MonitorCommand) Remove() *MonitorCommand {
+	c.b.terminate("--remove")
+	return c
+}
+
+func (c *MonitorCommand) Args() ([]string, error) {
+	return c.b.build()
+}
+
+type RuleCommand struct {
+	b builder
+}
+
+// Rule adds a rule for windows with the given class and instance names, where an empty name matches any.
+func Rule(className, instanceName string) *RuleCommand {
+	c := &RuleCommand{b: newBuilder("rule", nil)}
+	c.b.addOnce("--add", ruleName(className)+":"+ruleName(instanceName))
+	return c
+}
+
+func (c *RuleCommand) OneShot() *RuleCommand {
+	c.b.addOnce("--one-shot")
+	return c
+}
+
+// Node places the matching window at the given node, e.g. a receptacle.
+func (c *RuleCommand) Node(n NodeSelector) *RuleCommand {
+	sel, err := commandSelector(n.selector)
+	if err != nil {
+		c.b.fail("node: %v", err)
+		return c
+	}
+	if c.b.used["node"] {
+		c.b.fail("duplicate node")
+		return c
+	}
+	c.b.used["node"] = true
+	c.b.add("node=" + sel)
+	return c
+}
+
+func (c *RuleCommand) Args() ([]string, error) {
+	return c.b.build()
+}
+
+func (c *Client) Exec(ctx context.Context, cmd Command) ([]byte, error) {
+	args, err := cmd.Args()
+	if err != nil {
+		return nil, xerrors.Errorf("bspc exec: %w", err)
+	}
+	return c.Run(ctx, args...)
+}
+
+func Exec(cmd Command) ([]byte, error) {
+	args, err := cmd.Args()
+	if err != nil {
+		return nil, xerrors.Errorf("bspc exec: %w", err)
+	}
+	return Run(args...)
+}
+
+func commandSelector(s selector) (string, error) {
+	sel, err := s.selectorString()
+	if err != nil {
+		return "", err
+	}
+	if sel == "" {
+		return "", xerrors.Errorf("%s selector: no descriptor or modifiers", s.domain)
+	}
+	return sel, nil
+}
+
+func nodeSelectors(sel []NodeSelector) []selector {
+	result := make([]selector, 0, len(sel))
+	for _, s := range sel {
+		result = append(result, s.selector)
+	}
+	return result
+}
+
+func desktopSelectors(sel []DesktopSelector) []selector {
+	result := make([]selector, 0, len(sel))
+	for _, s := range sel {
+		result = append(result, s.selector)
+	}
+	return result
+}
+
+func monitorSelectors(sel []MonitorSelector) []selector {
+	result := make([]selector, 0, len(sel))
+	for _, s := range sel {
+		result = append(result, s.selector)
+	}
+	return result
+}
+
+func ruleName(name string) string {
+	if name == "" {
+		return "*"
+	}
+	return name
+}
+
+func formatRatio(r float64) string {
+	return strconv.FormatFloat(r, 'f', -1, 64)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
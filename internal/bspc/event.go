@@ -0,0 +1,445 @@
+package bspc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type Event interface {
+	event()
+}
+
+type Geometry struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+type MonitorAdd struct {
+	MonitorID int
+	Name      string
+	Geometry  Geometry
+}
+
+type MonitorRename struct {
+	MonitorID int
+	OldName   string
+	NewName   string
+}
+
+type MonitorRemove struct {
+	MonitorID int
+}
+
+type MonitorSwap struct {
+	SrcMonitorID int
+	DstMonitorID int
+}
+
+type MonitorFocus struct {
+	MonitorID int
+}
+
+type MonitorGeometry struct {
+	MonitorID int
+	Geometry  Geometry
+}
+
+type DesktopAdd struct {
+	MonitorID int
+	DesktopID int
+	Name      string
+}
+
+type DesktopRename struct {
+	MonitorID int
+	DesktopID int
+	OldName   string
+	NewName   string
+}
+
+type DesktopRemove struct {
+	MonitorID int
+	DesktopID int
+}
+
+type DesktopSwap struct {
+	SrcMonitorID int
+	SrcDesktopID int
+	DstMonitorID int
+	DstDesktopID int
+}
+
+type DesktopTransfer struct {
+	SrcMonitorID int
+	SrcDesktopID int
+	DstMonitorID int
+}
+
+type DesktopFocus struct {
+	MonitorID int
+	DesktopID int
+}
+
+type DesktopActivate struct {
+	MonitorID int
+	DesktopID int
+}
+
+type DesktopLayout struct {
+	MonitorID int
+	DesktopID int
+	Layout    string
+}
+
+type NodeAdd struct {
+	MonitorID        int
+	DesktopID        int
+	InsertionPointID int
+	NodeID           int
+}
+
+type NodeRemove struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+}
+
+type NodeSwap struct {
+	SrcMonitorID int
+	SrcDesktopID int
+	SrcNodeID    int
+	DstMonitorID int
+	DstDesktopID int
+	DstNodeID    int
+}
+
+type NodeTransfer struct {
+	SrcMonitorID int
+	SrcDesktopID int
+	SrcNodeID    int
+	DstMonitorID int
+	DstDesktopID int
+	DstNodeID    int
+}
+
+type NodeFocus struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+}
+
+type NodeActivate struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+}
+
+type NodePresel struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	// Kind is one of "dir", "ratio" or "cancel".
+	Kind  string
+	Value string
+}
+
+type NodeStack struct {
+	NodeID      int
+	Relation    string
+	OtherNodeID int
+}
+
+type NodeGeometry struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	Geometry  Geometry
+}
+
+type NodeState struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	State     string
+	On        bool
+}
+
+type NodeFlag struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	Flag      string
+	On        bool
+}
+
+type NodeLayer struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	Layer     string
+}
+
+type PointerAction struct {
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	Action    string
+	Phase     string
+}
+
+type Report struct {
+	Monitors []*ReportMonitor
+}
+
+type ReportMonitor struct {
+	Name     string
+	Focused  bool
+	Desktops []*ReportDesktop
+	Layout   string
+	State    string
+	Flags    string
+}
+
+type ReportDesktop struct {
+	Name     string
+	Focused  bool
+	Occupied bool
+	Urgent   bool
+}
+
+type Unknown struct {
+	Name   string
+	Fields []string
+}
+
+func (*MonitorAdd) event()      {}
+func (*MonitorRename) event()   {}
+func (*MonitorRemove) event()   {}
+func (*MonitorSwap) event()     {}
+func (*MonitorFocus) event()    {}
+func (*MonitorGeometry) event() {}
+func (*DesktopAdd) event()      {}
+func (*DesktopRename) event()   {}
+func (*DesktopRemove) event()   {}
+func (*DesktopSwap) event()     {}
+func (*DesktopTransfer) event() {}
+func (*DesktopFocus) event()    {}
+func (*DesktopActivate) event() {}
+func (*DesktopLayout) event()   {}
+func (*NodeAdd) event()         {}
+func (*NodeRemove) event()      {}
+func (*NodeSwap) event()        {}
+func (*NodeTransfer) event()    {}
+func (*NodeFocus) event()       {}
+func (*NodeActivate) event()    {}
+func (*NodePresel) event()      {}
+func (*NodeStack) event()       {}
+func (*NodeGeometry) event()    {}
+func (*NodeState) event()       {}
+func (*NodeFlag) event()        {}
+func (*NodeLayer) event()       {}
+func (*PointerAction) event()   {}
+func (*Report) event()          {}
+func (*Unknown) event()         {}
+
+func ParseEvent(line string) (Event, error) {
+	if strings.HasPrefix(line, "W") {
+		r, err := parseReport(line[1:])
+		if err != nil {
+			return nil, xerrors.Errorf("parse event: %w", err)
+		}
+		return r, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, xerrors.New("parse event: empty line")
+	}
+	p := &eventParser{fields: fields[1:]}
+	var e Event
+	switch fields[0] {
+	case "monitor_add":
+		e = &MonitorAdd{MonitorID: p.id(), Name: p.str(), Geometry: p.geometry()}
+	case "monitor_rename":
+		e = &MonitorRename{MonitorID: p.id(), OldName: p.str(), NewName: p.str()}
+	case "monitor_remove":
+		e = &MonitorRemove{MonitorID: p.id()}
+	case "monitor_swap":
+		e = &MonitorSwap{SrcMonitorID: p.id(), DstMonitorID: p.id()}
+	case "monitor_focus":
+		e = &MonitorFocus{MonitorID: p.id()}
+	case "monitor_geometry":
+		e = &MonitorGeometry{MonitorID: p.id(), Geometry: p.geometry()}
+	case "desktop_add":
+		e = &DesktopAdd{MonitorID: p.id(), DesktopID: p.id(), Name: p.str()}
+	case "desktop_rename":
+		e = &DesktopRename{MonitorID: p.id(), DesktopID: p.id(), OldName: p.str(), NewName: p.str()}
+	case "desktop_remove":
+		e = &DesktopRemove{MonitorID: p.id(), DesktopID: p.id()}
+	case "desktop_swap":
+		e = &DesktopSwap{SrcMonitorID: p.id(), SrcDesktopID: p.id(), DstMonitorID: p.id(), DstDesktopID: p.id()}
+	case "desktop_transfer":
+		e = &DesktopTransfer{SrcMonitorID: p.id(), SrcDesktopID: p.id(), DstMonitorID: p.id()}
+	case "desktop_focus":
+		e = &DesktopFocus{MonitorID: p.id(), DesktopID: p.id()}
+	case "desktop_activate":
+		e = &DesktopActivate{MonitorID: p.id(), DesktopID: p.id()}
+	case "desktop_layout":
+		e = &DesktopLayout{MonitorID: p.id(), DesktopID: p.id(), Layout: p.str()}
+	case "node_add":
+		e = &NodeAdd{MonitorID: p.id(), DesktopID: p.id(), InsertionPointID: p.id(), NodeID: p.id()}
+	case "node_remove":
+		e = &NodeRemove{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id()}
+	case "node_swap":
+		e = &NodeSwap{
+			SrcMonitorID: p.id(), SrcDesktopID: p.id(), SrcNodeID: p.id(),
+			DstMonitorID: p.id(), DstDesktopID: p.id(), DstNodeID: p.id(),
+		}
+	case "node_transfer":
+		e = &NodeTransfer{
+			SrcMonitorID: p.id(), SrcDesktopID: p.id(), SrcNodeID: p.id(),
+			DstMonitorID: p.id(), DstDesktopID: p.id(), DstNodeID: p.id(),
+		}
+	case "node_focus":
+		e = &NodeFocus{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id()}
+	case "node_activate":
+		e = &NodeActivate{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id()}
+	case "node_presel":
+		n := &NodePresel{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), Kind: p.str()}
+		if n.Kind != "cancel" {
+			n.Value = p.str()
+		}
+		e = n
+	case "node_stack":
+		e = &NodeStack{NodeID: p.id(), Relation: p.str(), OtherNodeID: p.id()}
+	case "node_geometry":
+		e = &NodeGeometry{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), Geometry: p.geometry()}
+	case "node_state":
+		e = &NodeState{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), State: p.str(), On: p.onOff()}
+	case "node_flag":
+		e = &NodeFlag{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), Flag: p.str(), On: p.onOff()}
+	case "node_layer":
+		e = &NodeLayer{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), Layer: p.str()}
+	case "pointer_action":
+		e = &PointerAction{MonitorID: p.id(), DesktopID: p.id(), NodeID: p.id(), Action: p.str(), Phase: p.str()}
+	default:
+		return &Unknown{Name: fields[0], Fields: fields[1:]}, nil
+	}
+	if p.err != nil {
+		return nil, xerrors.Errorf("parse event %s: %w", fields[0], p.err)
+	}
+	return e, nil
+}
+
+type eventParser struct {
+	fields []string
+	err    error
+}
+
+func (p *eventParser) str() string {
+	if p.err != nil {
+		return ""
+	}
+	if len(p.fields) == 0 {
+		p.err = xerrors.New("too few fields")
+		return ""
+	}
+	s := p.fields[0]
+	p.fields = p.fields[1:]
+	return s
+}
+
+func (p *eventParser) id() int {
+	s := p.str()
+	if p.err != nil {
+		return 0
+	}
+	id, err := parseID(s)
+	if err != nil {
+		p.err = err
+	}
+	return id
+}
+
+func (p *eventParser) geometry() Geometry {
+	s := p.str()
+	if p.err != nil {
+		return Geometry{}
+	}
+	g, err := parseGeometry(s)
+	if err != nil {
+		p.err = err
+	}
+	return g
+}
+
+func (p *eventParser) onOff() bool {
+	switch s := p.str(); s {
+	case "on":
+		return true
+	case "off":
+		return false
+	default:
+		if p.err == nil {
+			p.err = xerrors.Errorf("malformed on/off: %s", s)
+		}
+		return false
+	}
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return 0, xerrors.Errorf("parse id: %w", err)
+	}
+	return int(id), nil
+}
+
+func parseGeometry(s string) (Geometry, error) {
+	var g Geometry
+	if _, err := fmt.Sscanf(s, "%dx%d+%d+%d", &g.Width, &g.Height, &g.X, &g.Y); err != nil {
+		return Geometry{}, xerrors.Errorf("parse geometry %s: %w", s, err)
+	}
+	return g, nil
+}
+
+func parseReport(s string) (*Report, error) {
+	var r Report
+	var m *ReportMonitor
+	for _, item := range strings.Split(s, ":") {
+		if item == "" {
+			continue
+		}
+		key, value := item[0], item[1:]
+		if key == 'M' || key == 'm' {
+			m = &ReportMonitor{Name: value, Focused: key == 'M'}
+			r.Monitors = append(r.Monitors, m)
+			continue
+		}
+		if m == nil {
+			return nil, xerrors.Errorf("parse report: item before monitor: %s", item)
+		}
+		switch key {
+		case 'O', 'o', 'F', 'f', 'U', 'u':
+			m.Desktops = append(m.Desktops, &ReportDesktop{
+				Name:     value,
+				Focused:  key == 'O' || key == 'F' || key == 'U',
+				Occupied: key != 'F' && key != 'f',
+				Urgent:   key == 'U' || key == 'u',
+			})
+		case 'L':
+			m.Layout = value
+		case 'T':
+			m.State = value
+		case 'G':
+			m.Flags = value
+		default:
+			return nil, xerrors.Errorf("parse report: unknown item: %s", item)
+		}
+	}
+	return &r, nil
+}
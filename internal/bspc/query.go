@@ -0,0 +1,153 @@
+package bspc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	"golang.org/x/xerrors"
+)
+
+type Selector interface {
+	queryFlag() string
+	selectorString() (string, error)
+}
+
+func (NodeSelector) queryFlag() string {
+	return "-n"
+}
+
+func (DesktopSelector) queryFlag() string {
+	return "-d"
+}
+
+func (MonitorSelector) queryFlag() string {
+	return "-m"
+}
+
+func (c *Client) QueryNodes(ctx context.Context, sel NodeSelector, scope ...Selector) ([]int, error) {
+	ids, err := c.queryIDs(ctx, "-N", append([]Selector{sel}, scope...))
+	if err != nil {
+		return nil, xerrors.Errorf("query nodes: %w", err)
+	}
+	return ids, nil
+}
+
+func (c *Client) QueryDesktops(ctx context.Context, sel DesktopSelector, scope ...Selector) ([]int, error) {
+	ids, err := c.queryIDs(ctx, "-D", append([]Selector{sel}, scope...))
+	if err != nil {
+		return nil, xerrors.Errorf("query desktops: %w", err)
+	}
+	return ids, nil
+}
+
+func (c *Client) QueryMonitors(ctx context.Context, sel MonitorSelector, scope ...Selector) ([]int, error) {
+	ids, err := c.queryIDs(ctx, "-M", append([]Selector{sel}, scope...))
+	if err != nil {
+		return nil, xerrors.Errorf("query monitors: %w", err)
+	}
+	return ids, nil
+}
+
+func (c *Client) QueryTree(ctx context.Context, sel Selector) ([]byte, error) {
+	args, err := queryArgs("-T", []Selector{sel})
+	if err != nil {
+		return nil, xerrors.Errorf("query tree: %w", err)
+	}
+	response, err := c.Run(ctx, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("query tree: %w", err)
+	}
+	return response, nil
+}
+
+func QueryNodes(sel NodeSelector, scope ...Selector) ([]int, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("query nodes: %w", err)
+	}
+	return c.QueryNodes(context.Background(), sel, scope...)
+}
+
+func QueryDesktops(sel DesktopSelector, scope ...Selector) ([]int, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("query desktops: %w", err)
+	}
+	return c.QueryDesktops(context.Background(), sel, scope...)
+}
+
+func QueryMonitors(sel MonitorSelector, scope ...Selector) ([]int, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("query monitors: %w", err)
+	}
+	return c.QueryMonitors(context.Background(), sel, scope...)
+}
+
+func QueryTree(sel Selector) ([]byte, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("query tree: %w", err)
+	}
+	return c.QueryTree(context.Background(), sel)
+}
+
+func (c *Client) queryIDs(ctx context.Context, domainFlag string, sels []Selector) ([]int, error) {
+	args, err := queryArgs(domainFlag, sels)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.Run(ctx, args...)
+	if err != nil {
+		var bspcErr *Error
+		if xerrors.As(err, &bspcErr) && bspcErr.Message == "" {
+			// bspwm replies with an empty failure when nothing matches.
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []int
+	sc := bufio.NewScanner(bytes.NewReader(response))
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		id, err := parseID(string(line))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if sc.Err() != nil {
+		return nil, sc.Err()
+	}
+	return ids, nil
+}
+
+func queryArgs(domainFlag string, sels []Selector) ([]string, error) {
+	args := []string{"query", domainFlag}
+	used := make(map[string]bool, len(sels))
+	for _, sel := range sels {
+		flag := sel.queryFlag()
+		if used[flag] {
+			return nil, xerrors.Errorf("duplicate %s selector", flag)
+		}
+		used[flag] = true
+		s, err := sel.selectorString()
+		if err != nil {
+			return nil, err
+		}
+		if s == "" {
+			// A tree query is rejected without a domain flag, and a bare flag names the focused element.
+			// Other queries match everything when the flag is left out.
+			if domainFlag == "-T" {
+				args = append(args, flag)
+			}
+			continue
+		}
+		args = append(args, flag, s)
+	}
+	return args, nil
+}
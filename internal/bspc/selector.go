@@ -0,0 +1,323 @@
+package bspc
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+type Descriptor string
+
+const (
+	Any           Descriptor = "any"
+	FirstAncestor Descriptor = "first_ancestor"
+	Last          Descriptor = "last"
+	Newest        Descriptor = "newest"
+	Older         Descriptor = "older"
+	Newer         Descriptor = "newer"
+	Focused       Descriptor = "focused"
+	Pointed       Descriptor = "pointed"
+	Biggest       Descriptor = "biggest"
+	Smallest      Descriptor = "smallest"
+	Primary       Descriptor = "primary"
+	Next          Descriptor = "next"
+	Prev          Descriptor = "prev"
+	North         Descriptor = "north"
+	West          Descriptor = "west"
+	South         Descriptor = "south"
+	East          Descriptor = "east"
+)
+
+type NodeModifier string
+
+const (
+	NodeFocused      NodeModifier = "focused"
+	NodeActive       NodeModifier = "active"
+	NodeAutomatic    NodeModifier = "automatic"
+	NodeLocal        NodeModifier = "local"
+	NodeLeaf         NodeModifier = "leaf"
+	NodeWindow       NodeModifier = "window"
+	NodeTiled        NodeModifier = "tiled"
+	NodePseudoTiled  NodeModifier = "pseudo_tiled"
+	NodeFloating     NodeModifier = "floating"
+	NodeFullscreen   NodeModifier = "fullscreen"
+	NodeHidden       NodeModifier = "hidden"
+	NodeSticky       NodeModifier = "sticky"
+	NodePrivate      NodeModifier = "private"
+	NodeLocked       NodeModifier = "locked"
+	NodeMarked       NodeModifier = "marked"
+	NodeUrgent       NodeModifier = "urgent"
+	NodeBelow        NodeModifier = "below"
+	NodeNormal       NodeModifier = "normal"
+	NodeAbove        NodeModifier = "above"
+	NodeHorizontal   NodeModifier = "horizontal"
+	NodeVertical     NodeModifier = "vertical"
+	NodeSameClass    NodeModifier = "same_class"
+	NodeDescendantOf NodeModifier = "descendant_of"
+	NodeAncestorOf   NodeModifier = "ancestor_of"
+)
+
+type DesktopModifier string
+
+const (
+	DesktopFocused     DesktopModifier = "focused"
+	DesktopActive      DesktopModifier = "active"
+	DesktopOccupied    DesktopModifier = "occupied"
+	DesktopLocal       DesktopModifier = "local"
+	DesktopUrgent      DesktopModifier = "urgent"
+	DesktopTiled       DesktopModifier = "tiled"
+	DesktopMonocle     DesktopModifier = "monocle"
+	DesktopUserTiled   DesktopModifier = "user_tiled"
+	DesktopUserMonocle DesktopModifier = "user_monocle"
+)
+
+type MonitorModifier string
+
+const (
+	MonitorFocused  MonitorModifier = "focused"
+	MonitorOccupied MonitorModifier = "occupied"
+)
+
+var (
+	nodeDescriptors = descriptorSet(
+		Any, FirstAncestor, Last, Newest, Older, Newer, Focused, Pointed, Biggest, Smallest,
+		Next, Prev, North, West, South, East,
+	)
+	desktopDescriptors = descriptorSet(
+		Any, Last, Newest, Older, Newer, Focused, Next, Prev,
+	)
+	monitorDescriptors = descriptorSet(
+		Any, Last, Newest, Older, Newer, Focused, Pointed, Primary, Next, Prev, North, West, South, East,
+	)
+	nodeModifiers = modifierSet(
+		NodeFocused, NodeActive, NodeAutomatic, NodeLocal, NodeLeaf, NodeWindow, NodeTiled,
+		NodePseudoTiled, NodeFloating, NodeFullscreen, NodeHidden, NodeSticky, NodePrivate,
+		NodeLocked, NodeMarked, NodeUrgent, NodeBelow, NodeNormal, NodeAbove, NodeHorizontal,
+		NodeVertical, NodeSameClass, NodeDescendantOf, NodeAncestorOf,
+	)
+	desktopModifiers = modifierSet(
+		DesktopFocused, DesktopActive, DesktopOccupied, DesktopLocal, DesktopUrgent,
+		DesktopTiled, DesktopMonocle, DesktopUserTiled, DesktopUserMonocle,
+	)
+	monitorModifiers = modifierSet(
+		MonitorFocused, MonitorOccupied,
+	)
+)
+
+type selector struct {
+	domain    string
+	all       bool
+	desc      string
+	modifiers []string
+	err       error
+}
+
+type NodeSelector struct {
+	selector
+}
+
+type DesktopSelector struct {
+	selector
+}
+
+type MonitorSelector struct {
+	selector
+}
+
+func AllNodes() NodeSelector {
+	return NodeSelector{selector{domain: "node", all: true}}
+}
+
+func NodeID(id int) NodeSelector {
+	return NodeSelector{idSelector("node", id)}
+}
+
+func NodeDesc(d Descriptor) NodeSelector {
+	return NodeSelector{descSelector("node", d, nodeDescriptors)}
+}
+
+func NodePath(d DesktopSelector, path string) NodeSelector {
+	s := selector{domain: "node"}
+	desktop, err := d.selectorString()
+	switch {
+	case err != nil:
+		s.err = err
+	case !strings.HasPrefix(path, "/"):
+		s.err = xerrors.Errorf("node selector: malformed path: %s", path)
+	default:
+		s.desc = "@" + desktop + ":" + path
+	}
+	return NodeSelector{s}
+}
+
+func (s NodeSelector) With(ms ...NodeModifier) NodeSelector {
+	for _, m := range ms {
+		s.selector = s.modify("", string(m), nodeModifiers)
+	}
+	return s
+}
+
+func (s NodeSelector) Without(ms ...NodeModifier) NodeSelector {
+	for _, m := range ms {
+		s.selector = s.modify("!", string(m), nodeModifiers)
+	}
+	return s
+}
+
+func AllDesktops() DesktopSelector {
+	return DesktopSelector{selector{domain: "desktop", all: true}}
+}
+
+func DesktopID(id int) DesktopSelector {
+	return DesktopSelector{idSelector("desktop", id)}
+}
+
+func DesktopDesc(d Descriptor) DesktopSelector {
+	return DesktopSelector{descSelector("desktop", d, desktopDescriptors)}
+}
+
+func DesktopName(name string) DesktopSelector {
+	return DesktopSelector{nameSelector("desktop", name)}
+}
+
+func DesktopIndex(i int) DesktopSelector {
+	return DesktopSelector{indexSelector("desktop", i)}
+}
+
+func (s DesktopSelector) With(ms ...DesktopModifier) DesktopSelector {
+	for _, m := range ms {
+		s.selector = s.modify("", string(m), desktopModifiers)
+	}
+	return s
+}
+
+func (s DesktopSelector) Without(ms ...DesktopModifier) DesktopSelector {
+	for _, m := range ms {
+		s.selector = s.modify("!", string(m), desktopModifiers)
+	}
+	return s
+}
+
+func AllMonitors() MonitorSelector {
+	return MonitorSelector{selector{domain: "monitor", all: true}}
+}
+
+func MonitorID(id int) MonitorSelector {
+	return MonitorSelector{idSelector("monitor", id)}
+}
+
+func MonitorDesc(d Descriptor) MonitorSelector {
+	return MonitorSelector{descSelector("monitor", d, monitorDescriptors)}
+}
+
+func MonitorName(name string) MonitorSelector {
+	return MonitorSelector{nameSelector("monitor", name)}
+}
+
+func MonitorIndex(i int) MonitorSelector {
+	return MonitorSelector{indexSelector("monitor", i)}
+}
+
+func (s MonitorSelector) With(ms ...MonitorModifier) MonitorSelector {
+	for _, m := range ms {
+		s.selector = s.modify("", string(m), monitorModifiers)
+	}
+	return s
+}
+
+func (s MonitorSelector) Without(ms ...MonitorModifier) MonitorSelector {
+	for _, m := range ms {
+		s.selector = s.modify("!", string(m), monitorModifiers)
+	}
+	return s
+}
+
+func (s selector) String() string {
+	str, err := s.selectorString()
+	if err != nil {
+		return "<invalid>"
+	}
+	return str
+}
+
+func (s selector) selectorString() (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if s.desc == "" && !s.all {
+		return "", xerrors.New("empty selector")
+	}
+	var b strings.Builder
+	b.WriteString(s.desc)
+	for _, m := range s.modifiers {
+		b.WriteString(".")
+		b.WriteString(m)
+	}
+	return b.String(), nil
+}
+
+func (s selector) modify(prefix string, m string, valid map[string]bool) selector {
+	if s.err != nil {
+		return s
+	}
+	if !valid[m] {
+		s.err = xerrors.Errorf("%s selector: invalid modifier: %s", s.domain, m)
+		return s
+	}
+	modifiers := make([]string, len(s.modifiers), len(s.modifiers)+1)
+	copy(modifiers, s.modifiers)
+	s.modifiers = append(modifiers, prefix+m)
+	return s
+}
+
+func idSelector(domain string, id int) selector {
+	if id <= 0 {
+		return selector{domain: domain, err: xerrors.Errorf("%s selector: invalid id: %d", domain, id)}
+	}
+	return selector{domain: domain, desc: strconv.Itoa(id)}
+}
+
+func descSelector(domain string, d Descriptor, valid map[string]bool) selector {
+	if !valid[string(d)] {
+		return selector{domain: domain, err: xerrors.Errorf("%s selector: invalid descriptor: %s", domain, d)}
+	}
+	return selector{domain: domain, desc: string(d)}
+}
+
+func nameSelector(domain string, name string) selector {
+	if name == "" || strings.ContainsAny(name, ".#:") {
+		return selector{domain: domain, err: xerrors.Errorf("%s selector: invalid name: %q", domain, name)}
+	}
+	return selector{domain: domain, desc: name}
+}
+
+func indexSelector(domain string, i int) selector {
+	if i <= 0 {
+		return selector{domain: domain, err: xerrors.Errorf("%s selector: invalid index: %d", domain, i)}
+	}
+	return selector{domain: domain, desc: "^" + strconv.Itoa(i)}
+}
+
+func descriptorSet(ds ...Descriptor) map[string]bool {
+	result := make(map[string]bool, len(ds))
+	for _, d := range ds {
+		result[string(d)] = true
+	}
+	return result
+}
+
+func modifierSet(ms ...interface{}) map[string]bool {
+	result := make(map[string]bool, len(ms))
+	for _, m := range ms {
+		switch m := m.(type) {
+		case NodeModifier:
+			result[string(m)] = true
+		case DesktopModifier:
+			result[string(m)] = true
+		case MonitorModifier:
+			result[string(m)] = true
+		}
+	}
+	return result
+}
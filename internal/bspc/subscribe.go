@@ -0,0 +1,94 @@
+package bspc
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+type Subscription struct {
+	events chan Event
+	mu     sync.Mutex
+	err    error
+}
+
+func (c *Client) Subscribe(ctx context.Context, events ...string) (*Subscription, error) {
+	socket, err := c.dial(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("bspc subscribe: %w", err)
+	}
+	args := append([]string{"subscribe"}, events...)
+	if _, err := socket.Write(encodeMessage(args)); err != nil {
+		_ = socket.Close()
+		return nil, xerrors.Errorf("bspc subscribe: %w", err)
+	}
+	s := &Subscription{events: make(chan Event)}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = socket.Close()
+	}()
+	go func() {
+		defer close(s.events)
+		defer close(done)
+		s.setErr(s.read(ctx, socket, args))
+	}()
+	return s, nil
+}
+
+func Subscribe(ctx context.Context, events ...string) (*Subscription, error) {
+	c, err := NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("bspc subscribe: %w", err)
+	}
+	return c.Subscribe(ctx, events...)
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *Subscription) read(ctx context.Context, socket net.Conn, args []string) error {
+	sc := bufio.NewScanner(socket)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, failureMarker) {
+			return &Error{Args: args, Message: strings.TrimPrefix(line, failureMarker)}
+		}
+		e, err := ParseEvent(line)
+		if err != nil {
+			return xerrors.Errorf("bspc subscribe: %w", err)
+		}
+		select {
+		case s.events <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if sc.Err() != nil {
+		return xerrors.Errorf("bspc subscribe: %w", sc.Err())
+	}
+	return xerrors.New("bspc subscribe: connection closed")
+}
@@ -0,0 +1,227 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const (
+	dialTimeout    = time.Second
+	requestTimeout = 30 * time.Second
+)
+
+var ErrNoDaemon = xerrors.New("daemon not running")
+
+type Request struct {
+	Args []string `json:"args"`
+}
+
+type Response struct {
+	Output string      `json:"output,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	Code   string      `json:"code,omitempty"`
+	Bspc   *bspc.Error `json:"bspc,omitempty"`
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   = map[string]error{}
+)
+
+// RegisterError makes err survive the control socket: a daemon error matching err is reported with code,
+// and the caller's Error unwraps to err again.
+func RegisterError(code string, err error) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered[code] = err
+}
+
+func errorCode(err error) string {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	for code, target := range registered {
+		if xerrors.Is(err, target) {
+			return code
+		}
+	}
+	return ""
+}
+
+func registeredError(code string) error {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	return registered[code]
+}
+
+type Error struct {
+	Args    []string
+	Message string
+	Code    string
+	Bspc    *bspc.Error
+}
+
+func (e *Error) Error() string {
+	return "daemon " + strings.Join(e.Args, " ") + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	if err := registeredError(e.Code); err != nil {
+		return err
+	}
+	if e.Bspc != nil {
+		return e.Bspc
+	}
+	return nil
+}
+
+type Handler func(ctx context.Context, args []string) (string, error)
+
+func SocketPath() (string, error) {
+	runtimeDir, err := xdg.RuntimeDir()
+	if err != nil {
+		return "", xerrors.Errorf("control socket path: %w", err)
+	}
+	return filepath.Join(runtimeDir, "bspwmrc", "control.sock"), nil
+}
+
+type Server struct {
+	logger   *log.Logger
+	handler  Handler
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// Listen binds the control socket, replacing any socket left behind by an earlier daemon.
+func Listen(path string, logger *log.Logger, handler Handler) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, xerrors.Errorf("listen control socket: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("listen control socket: %w", err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, xerrors.Errorf("listen control socket: %w", err)
+	}
+	return &Server{logger: logger, handler: handler, listener: l}, nil
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		_ = s.listener.Close()
+	}()
+	defer s.wg.Wait()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return xerrors.Errorf("serve control socket: %w", err)
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			s.logger.Printf("control: %v", err)
+		}
+	}()
+	if err := conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		s.logger.Printf("control: %v", err)
+		return
+	}
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		s.logger.Printf("control: decode request: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var resp Response
+	output, err := s.handle(ctx, req.Args)
+	resp.Output = output
+	if err != nil {
+		s.logger.Printf("control %v: %v", req.Args, err)
+		resp.Error = err.Error()
+		resp.Code = errorCode(err)
+		var bspcErr *bspc.Error
+		if xerrors.As(err, &bspcErr) {
+			resp.Bspc = bspcErr
+		}
+	}
+	if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+		s.logger.Printf("control: encode response: %v", err)
+	}
+}
+
+func (s *Server) handle(ctx context.Context, args []string) (_ string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("panic: %+v", r)
+		}
+	}()
+	return s.handler(ctx, args)
+}
+
+// Call forwards args to the daemon and returns its output, or ErrNoDaemon when no daemon is listening.
+func Call(ctx context.Context, path string, args ...string) (string, error) {
+	d := net.Dialer{Timeout: dialTimeout}
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		if isNoDaemon(err) {
+			return "", ErrNoDaemon
+		}
+		return "", xerrors.Errorf("call daemon: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	deadline := time.Now().Add(requestTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", xerrors.Errorf("call daemon: %w", err)
+	}
+	if err := json.NewEncoder(conn).Encode(&Request{Args: args}); err != nil {
+		return "", xerrors.Errorf("call daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", xerrors.Errorf("call daemon: %w", err)
+	}
+	if resp.Error != "" {
+		return resp.Output, &Error{Args: args, Message: resp.Error, Code: resp.Code, Bspc: resp.Bspc}
+	}
+	return resp.Output, nil
+}
+
+func isNoDaemon(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if syscallErr, ok := err.(*os.SyscallError); ok {
+		err = syscallErr.Err
+	}
+	return err == syscall.ENOENT || err == syscall.ECONNREFUSED
+}
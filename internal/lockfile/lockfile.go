@@ -0,0 +1,63 @@
+package lockfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/xerrors"
+)
+
+var ErrLocked = xerrors.New("locked")
+
+type Lock struct {
+	f *os.File
+}
+
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, xerrors.Errorf("acquire lock: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, xerrors.Errorf("acquire lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLocked
+		}
+		return nil, xerrors.Errorf("acquire lock: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("acquire lock: %w", err)
+	}
+	if _, err := f.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("acquire lock: %w", err)
+	}
+	return &Lock{f: f}, nil
+}
+
+func (l *Lock) Release() error {
+	if err := l.f.Close(); err != nil {
+		return xerrors.Errorf("release lock: %w", err)
+	}
+	return nil
+}
+
+func Owner(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, xerrors.Errorf("lock owner: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, xerrors.Errorf("lock owner: %w", err)
+	}
+	return pid, nil
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+// Urgency of a notification with the values of the spec's byte hint.
+type Urgency byte
+
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+// ExpireNever keeps a notification open until it is dismissed.
+const ExpireNever time.Duration = -1
+
+const defaultAppName = "bspwmrc"
+
+type Action struct {
+	Key   string
+	Label string
+}
+
+type Notification struct {
+	AppName string
+	// ReplacesID is the ID of a notification to update in place, zero for a new notification.
+	ReplacesID uint32
+	Icon       string
+	Summary    string
+	Body       string
+	Actions    []Action
+	Category   string
+	Hints      map[string]dbus.Variant
+	// Expire is the display time, zero for the server default.
+	Expire time.Duration
+	// urgency is only sent when hasUrgency is set, otherwise it is left to the server.
+	urgency    Urgency
+	hasUrgency bool
+}
+
+func (n *Notification) SetUrgency(u Urgency) {
+	n.urgency = u
+	n.hasUrgency = true
+}
+
+func (n *Notification) appName() string {
+	if n.AppName == "" {
+		return defaultAppName
+	}
+	return n.AppName
+}
+
+func (n *Notification) actions() []string {
+	result := make([]string, 0, 2*len(n.Actions))
+	for _, a := range n.Actions {
+		result = append(result, a.Key, a.Label)
+	}
+	return result
+}
+
+func (n *Notification) hints() map[string]dbus.Variant {
+	result := make(map[string]dbus.Variant, len(n.Hints)+2)
+	for key, value := range n.Hints {
+		result[key] = value
+	}
+	if n.hasUrgency {
+		result["urgency"] = dbus.MakeVariant(byte(n.urgency))
+	}
+	if n.Category != "" {
+		result["category"] = dbus.MakeVariant(n.Category)
+	}
+	return result
+}
+
+func (n *Notification) expireTimeout() int32 {
+	switch {
+	case n.Expire == 0:
+		return -1
+	case n.Expire < 0:
+		return 0
+	}
+	return int32(n.Expire / time.Millisecond)
+}
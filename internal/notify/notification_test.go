@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus"
+)
+
+func TestNotification_Hints_Urgency(t *testing.T) {
+	var n Notification
+	if _, ok := n.hints()["urgency"]; ok {
+		t.Error("urgency hint sent without an urgency")
+	}
+	for _, u := range []Urgency{UrgencyLow, UrgencyNormal, UrgencyCritical} {
+		n.SetUrgency(u)
+		if actual, expected := n.hints()["urgency"], dbus.MakeVariant(byte(u)); actual != expected {
+			t.Errorf("urgency hint %v, expected %v", actual, expected)
+		}
+	}
+	if UrgencyLow != 0 || UrgencyNormal != 1 || UrgencyCritical != 2 {
+		t.Error("urgency values differ from the notification spec")
+	}
+}
@@ -0,0 +1,256 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+	"golang.org/x/xerrors"
+)
+
+const defaultServerWait = 2 * time.Second
+
+var errNoServer = xerrors.New("no notification server on the bus")
+
+// Notifier sends notifications over a shared session bus connection. When the notification server
+// cannot be reached, notifications are shown through the fallback sink, if any, instead of failing.
+type Notifier struct {
+	serverWait time.Duration
+	fallback   Sink
+	mu         sync.Mutex
+	conn       *dbus.Conn
+	// missing is set when the server did not appear in time, so that later calls fail at once.
+	missing bool
+}
+
+type Option func(*Notifier)
+
+// WithServerWait sets how long to wait for the notification server to appear on the bus,
+// e.g. while dunst is restarting.
+func WithServerWait(d time.Duration) Option {
+	return func(n *Notifier) {
+		n.serverWait = d
+	}
+}
+
+func WithFallback(s Sink) Option {
+	return func(n *Notifier) {
+		n.fallback = s
+	}
+}
+
+func NewNotifier(opts ...Option) *Notifier {
+	n := &Notifier{serverWait: defaultServerWait}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultNotifier = NewNotifier()
+)
+
+// SetDefault sets the notifier used by the package-level functions.
+func SetDefault(n *Notifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultNotifier = n
+}
+
+func getDefault() *Notifier {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultNotifier
+}
+
+func (n *Notifier) connection() (*dbus.Conn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn != nil {
+		return n.conn, nil
+	}
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, xerrors.Errorf("connect session bus: %w", err)
+	}
+	if err := conn.Auth(nil); err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("connect session bus: %w", err)
+	}
+	if err := conn.Hello(); err != nil {
+		_ = conn.Close()
+		return nil, xerrors.Errorf("connect session bus: %w", err)
+	}
+	n.conn = conn
+	return conn, nil
+}
+
+// reset drops conn after a failure so that the next call reconnects.
+func (n *Notifier) reset(conn *dbus.Conn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == conn {
+		_ = conn.Close()
+		n.conn = nil
+	}
+}
+
+// server returns the notification server object, waiting a bounded time for it to appear on the bus.
+func (n *Notifier) server() (*dbus.Conn, dbus.BusObject, error) {
+	conn, err := n.connection()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("notification server: %w", err)
+	}
+	if err := n.waitForServer(conn); err != nil {
+		n.reset(conn)
+		return nil, nil, xerrors.Errorf("notification server: %w", err)
+	}
+	return conn, conn.Object(busName, objectPath), nil
+}
+
+func (n *Notifier) setMissing(missing bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.missing = missing
+}
+
+func (n *Notifier) waitForServer(conn *dbus.Conn) error {
+	hasOwner := func() (bool, error) {
+		var ok bool
+		if err := conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, busName).Store(&ok); err != nil {
+			return false, err
+		}
+		if ok {
+			n.setMissing(false)
+		}
+		return ok, nil
+	}
+	if ok, err := hasOwner(); err != nil || ok {
+		return err
+	}
+	n.mu.Lock()
+	missing := n.missing
+	n.mu.Unlock()
+	if missing {
+		return errNoServer
+	}
+	rule := "type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus'," +
+		"member='NameOwnerChanged',arg0='" + busName + "'"
+	if call := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule); call.Err != nil {
+		return call.Err
+	}
+	defer conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, rule)
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	defer removeSignal(conn, signals)
+	// The name may have been acquired before the match was added.
+	if ok, err := hasOwner(); err != nil || ok {
+		return err
+	}
+	timeout := time.After(n.serverWait)
+	for {
+		select {
+		case <-timeout:
+			n.setMissing(true)
+			return errNoServer
+		case signal, ok := <-signals:
+			if !ok {
+				return xerrors.New("connection closed")
+			}
+			if signal.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(signal.Body) != 3 {
+				continue
+			}
+			if name, _ := signal.Body[0].(string); name != busName {
+				continue
+			}
+			if newOwner, _ := signal.Body[2].(string); newOwner != "" {
+				n.setMissing(false)
+				return nil
+			}
+		}
+	}
+}
+
+// Send shows n and returns the ID assigned by the notification server. When the notification is
+// shown through the fallback sink instead, the ID is zero.
+func (n *Notifier) Send(nt *Notification) (uint32, error) {
+	id, err := n.send(nt)
+	if err != nil {
+		if fallbackErr := n.showFallback(nt, err); fallbackErr != nil {
+			return 0, xerrors.Errorf("notify send: %w", err)
+		}
+		return 0, nil
+	}
+	return id, nil
+}
+
+func (n *Notifier) send(nt *Notification) (uint32, error) {
+	conn, obj, err := n.server()
+	if err != nil {
+		return 0, err
+	}
+	var id uint32
+	if err := obj.Call(
+		iface+".Notify",
+		0,
+		nt.appName(),
+		nt.ReplacesID,
+		nt.Icon,
+		nt.Summary,
+		nt.Body,
+		nt.actions(),
+		nt.hints(),
+		nt.expireTimeout(),
+	).Store(&id); err != nil {
+		if xerrors.Is(err, dbus.ErrClosed) {
+			n.reset(conn)
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (n *Notifier) showFallback(nt *Notification, cause error) error {
+	if n.fallback == nil {
+		return cause
+	}
+	return n.fallback.Show(nt, cause)
+}
+
+// Close closes the notification with the given ID. Closing ID zero, as returned for notifications
+// shown through the fallback sink, does nothing.
+func (n *Notifier) Close(id uint32) error {
+	if id == 0 {
+		return nil
+	}
+	_, obj, err := n.server()
+	if err != nil {
+		return xerrors.Errorf("notify close: %w", err)
+	}
+	if call := obj.Call(iface+".CloseNotification", 0, id); call.Err != nil {
+		return xerrors.Errorf("notify close: %w", call.Err)
+	}
+	return nil
+}
+
+func (n *Notifier) GetCapabilities() ([]string, error) {
+	_, obj, err := n.server()
+	if err != nil {
+		return nil, xerrors.Errorf("notify capabilities: %w", err)
+	}
+	var capabilities []string
+	if err := obj.Call(iface+".GetCapabilities", 0).Store(&capabilities); err != nil {
+		return nil, xerrors.Errorf("notify capabilities: %w", err)
+	}
+	return capabilities, nil
+}
+
+// SendAndWait shows n and waits for the user to invoke one of its actions. It returns the key of the
+// invoked action, or an empty string when the notification was closed without one or was shown
+// through the fallback sink. When ctx is done the notification is closed.
+func (n *Notifier) SendAndWait(ctx context.Context, nt *Notification) (string, error) {
+	return n.sendAndWait(ctx, nt, func(uint32) error { return nil })
+}
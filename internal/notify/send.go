@@ -1,41 +1,27 @@
 package notify
 
-import (
-	"time"
+import "context"
 
-	"github.com/godbus/dbus"
-	"golang.org/x/xerrors"
+const (
+	busName    = "org.freedesktop.Notifications"
+	objectPath = "/org/freedesktop/Notifications"
+	iface      = "org.freedesktop.Notifications"
 )
 
-func Send(summary string, body string, expire time.Duration) error {
-	conn, err := dbus.SessionBus()
-	if err != nil {
-		return xerrors.Errorf("notify send: %w", err)
-	}
-	obj := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
-	call := obj.Call(
-		"org.freedesktop.Notifications.Notify",
-		0,
-		"",
-		uint32(0),
-		"",
-		summary,
-		body,
-		[]string{},
-		map[string]dbus.Variant{},
-		int32(expire)/1e6)
-	if call.Err != nil {
-		return xerrors.Errorf("notify send: %w", call.Err)
-	}
-	if err := conn.Close(); err != nil {
-		return xerrors.Errorf("notify send: %w", err)
-	}
-	return nil
-	//var ret uint32
-	//err := call.Store(&ret)
-	//if err != nil {
-	//log.Printf("error getting uint32 ret value: %v", err)
-	//return ret, err
-	//}
-	//return ret, nil
+// Send shows n with the default notifier.
+func Send(n *Notification) (uint32, error) {
+	return getDefault().Send(n)
+}
+
+func Close(id uint32) error {
+	return getDefault().Close(id)
+}
+
+func GetCapabilities() ([]string, error) {
+	return getDefault().GetCapabilities()
+}
+
+// SendAndWait shows n with the default notifier and waits for the user's choice.
+func SendAndWait(ctx context.Context, n *Notification) (string, error) {
+	return getDefault().SendAndWait(ctx, n)
 }
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"log"
+	"strings"
+)
+
+// Sink shows notifications that could not be delivered to the notification server.
+type Sink interface {
+	Show(n *Notification, cause error) error
+}
+
+type loggerSink struct {
+	logger *log.Logger
+}
+
+// LoggerSink writes notifications to logger, e.g. one writing to stderr or syslog.
+func LoggerSink(logger *log.Logger) Sink {
+	return &loggerSink{logger: logger}
+}
+
+func (s *loggerSink) Show(n *Notification, cause error) error {
+	body := strings.Replace(strings.TrimSpace(n.Body), "\n", " / ", -1)
+	s.logger.Printf("notification: %s: %s (%v)", n.Summary, body, cause)
+	return nil
+}
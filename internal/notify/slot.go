@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+// Slot remembers the ID of the last notification sent through it, so that each notification replaces
+// the previous one in place, also across processes.
+type Slot struct {
+	path string
+}
+
+func NewSlot(name string) (*Slot, error) {
+	runtimeDir, err := xdg.RuntimeDir()
+	if err != nil {
+		return nil, xerrors.Errorf("new notification slot: %w", err)
+	}
+	return &Slot{path: filepath.Join(runtimeDir, "bspwmrc", "notifications", name)}, nil
+}
+
+func (s *Slot) Send(n *Notification) (uint32, error) {
+	replaces := *n
+	replaces.ReplacesID = s.id()
+	id, err := Send(&replaces)
+	if err != nil {
+		return 0, xerrors.Errorf("slot send: %w", err)
+	}
+	if err := s.store(id); err != nil {
+		return 0, xerrors.Errorf("slot send: %w", err)
+	}
+	return id, nil
+}
+
+// SendAndWait is like the package-level SendAndWait, replacing the previous notification in the slot.
+func (s *Slot) SendAndWait(ctx context.Context, n *Notification) (string, error) {
+	replaces := *n
+	replaces.ReplacesID = s.id()
+	action, err := getDefault().sendAndWait(ctx, &replaces, s.store)
+	if err != nil {
+		return "", xerrors.Errorf("slot send and wait: %w", err)
+	}
+	return action, nil
+}
+
+func (s *Slot) store(id uint32) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return xerrors.Errorf("store notification id: %w", err)
+	}
+	if err := ioutil.WriteFile(s.path, []byte(strconv.FormatUint(uint64(id), 10)+"\n"), 0600); err != nil {
+		return xerrors.Errorf("store notification id: %w", err)
+	}
+	return nil
+}
+
+// id returns the last ID sent through the slot, or zero when there is none.
+func (s *Slot) id() uint32 {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return 0
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(id)
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/godbus/dbus"
+	"golang.org/x/xerrors"
+)
+
+const (
+	signalActionInvoked      = "ActionInvoked"
+	signalNotificationClosed = "NotificationClosed"
+)
+
+// closedGrace is how long to wait for an action after the close signal. The connection delivers
+// signals concurrently, so the close can overtake the action that caused it.
+const closedGrace = 100 * time.Millisecond
+
+func (n *Notifier) sendAndWait(ctx context.Context, nt *Notification, sent func(id uint32) error) (string, error) {
+	conn, err := n.connection()
+	if err != nil {
+		return n.fallbackAction(nt, err)
+	}
+	// Subscribe before sending so that no signal for the new notification is missed.
+	for _, member := range []string{signalActionInvoked, signalNotificationClosed} {
+		if call := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, matchRule(member)); call.Err != nil {
+			n.reset(conn)
+			return n.fallbackAction(nt, call.Err)
+		}
+		defer conn.BusObject().Call("org.freedesktop.DBus.RemoveMatch", 0, matchRule(member))
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	defer removeSignal(conn, signals)
+	id, err := n.send(nt)
+	if err != nil {
+		return n.fallbackAction(nt, err)
+	}
+	if err := sent(id); err != nil {
+		return "", xerrors.Errorf("notify send and wait: %w", err)
+	}
+	var closed <-chan time.Time
+	for {
+		select {
+		case <-closed:
+			return "", nil
+		case <-ctx.Done():
+			if err := n.Close(id); err != nil {
+				return "", xerrors.Errorf("notify send and wait: %w", err)
+			}
+			return "", xerrors.Errorf("notify send and wait: %w", ctx.Err())
+		case signal, ok := <-signals:
+			if !ok {
+				return "", xerrors.New("notify send and wait: connection closed")
+			}
+			if signal.Path != objectPath || len(signal.Body) != 2 {
+				continue
+			}
+			if signalID, ok := signal.Body[0].(uint32); !ok || signalID != id {
+				continue
+			}
+			switch signal.Name {
+			case iface + "." + signalActionInvoked:
+				action, _ := signal.Body[1].(string)
+				return action, nil
+			case iface + "." + signalNotificationClosed:
+				if closed == nil {
+					closed = time.After(closedGrace)
+				}
+			}
+		}
+	}
+}
+
+// fallbackAction shows nt through the fallback sink, where no action can be invoked.
+func (n *Notifier) fallbackAction(nt *Notification, cause error) (string, error) {
+	if err := n.showFallback(nt, cause); err != nil {
+		return "", xerrors.Errorf("notify send and wait: %w", err)
+	}
+	return "", nil
+}
+
+func matchRule(member string) string {
+	return "type='signal',interface='" + iface + "',member='" + member + "',path='" + objectPath + "'"
+}
+
+// removeSignal unregisters ch while draining it, since the connection blocks on delivery.
+func removeSignal(conn *dbus.Conn, ch chan *dbus.Signal) {
+	done := make(chan struct{})
+	go func() {
+		conn.RemoveSignal(ch)
+		close(done)
+	}()
+	for {
+		select {
+		case <-ch:
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pactl
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const defaultSink = "@DEFAULT_SINK@"
+
+var volumePercentRegexp = regexp.MustCompile(`(\d+)%`)
+
+type Sink struct {
+	// Volume is the average volume across channels in percent.
+	Volume int
+	Muted  bool
+}
+
+func run(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("pactl", args...)
+	// The output is parsed, so it must not be localized.
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, xerrors.Errorf("pactl %s: %w: %s", strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return output, nil
+}
+
+func DefaultSink() (*Sink, error) {
+	volume, err := run("get-sink-volume", defaultSink)
+	if err != nil {
+		return nil, xerrors.Errorf("default sink: %w", err)
+	}
+	mute, err := run("get-sink-mute", defaultSink)
+	if err != nil {
+		return nil, xerrors.Errorf("default sink: %w", err)
+	}
+	var s Sink
+	if s.Volume, err = parseVolume(string(volume)); err != nil {
+		return nil, xerrors.Errorf("default sink: %w", err)
+	}
+	s.Muted = strings.TrimSpace(string(mute)) == "Mute: yes"
+	return &s, nil
+}
+
+// parseVolume parses the output of get-sink-volume, e.g.
+// "Volume: front-left: 32768 /  50% / -18.06 dB,   front-right: 32768 /  50% / -18.06 dB".
+func parseVolume(output string) (int, error) {
+	// Only consider the first line, the second one is the balance.
+	line := strings.SplitN(output, "\n", 2)[0]
+	matches := volumePercentRegexp.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return 0, xerrors.Errorf("parse volume: %q", line)
+	}
+	var sum int
+	for _, m := range matches {
+		i, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, xerrors.Errorf("parse volume: %w", err)
+		}
+		sum += i
+	}
+	return sum / len(matches), nil
+}
+
+// SetVolume sets the volume of the default sink in percent.
+func SetVolume(percent int) error {
+	if _, err := run("set-sink-volume", defaultSink, strconv.Itoa(percent)+"%"); err != nil {
+		return xerrors.Errorf("set volume: %w", err)
+	}
+	return nil
+}
+
+func ToggleMute() error {
+	if _, err := run("set-sink-mute", defaultSink, "toggle"); err != nil {
+		return xerrors.Errorf("toggle mute: %w", err)
+	}
+	return nil
+}
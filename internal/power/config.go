@@ -0,0 +1,51 @@
+package power
+
+import (
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const configFilename = "power.json"
+
+// Hooks are the commands to run on each transition, e.g. to reduce compositor effects on battery.
+type Hooks struct {
+	Plugged   [][]string `json:"plugged"`
+	Unplugged [][]string `json:"unplugged"`
+}
+
+func (h *Hooks) For(t Transition) [][]string {
+	switch t {
+	case Plugged:
+		return h.Plugged
+	case Unplugged:
+		return h.Unplugged
+	}
+	return nil
+}
+
+func ConfigPath() (string, error) {
+	return xdg.ConfigPath(configFilename)
+}
+
+// LoadHooks reads the hooks from the power config file, returning no hooks when it does not exist.
+func LoadHooks() (*Hooks, error) {
+	f, err := xdg.ReadConfig(configFilename)
+	if err != nil {
+		return nil, xerrors.Errorf("load power hooks: %w", err)
+	}
+	if f == nil {
+		return &Hooks{}, nil
+	}
+	var hooks Hooks
+	if err := f.Decode(&hooks); err != nil {
+		return nil, xerrors.Errorf("load power hooks: %w", err)
+	}
+	for _, cmds := range [][][]string{hooks.Plugged, hooks.Unplugged} {
+		for _, cmd := range cmds {
+			if len(cmd) == 0 || cmd[0] == "" {
+				return nil, xerrors.Errorf("parse %s: empty hook command", f.Path)
+			}
+		}
+	}
+	return &hooks, nil
+}
@@ -0,0 +1,144 @@
+package power
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/odsod/bspwmrc/internal/battery"
+	"golang.org/x/xerrors"
+)
+
+const (
+	TypeMains = "Mains"
+	TypeUSB   = "USB"
+)
+
+// Supply is an external power supply such as an AC adapter or USB-C charger.
+type Supply struct {
+	Name   string
+	Type   string
+	Online bool
+}
+
+// System is the aggregate power state of all batteries and supplies.
+type System struct {
+	Batteries []*battery.B
+	Supplies  []*Supply
+	// EnergyNow and EnergyFull are the totals in µWh across the batteries that report energy or charge.
+	EnergyNow  int
+	EnergyFull int
+	// Rate is the combined rate in µW, positive while charging and negative while discharging.
+	Rate   int
+	Status string
+}
+
+// Charge is the battery charge weighted by full energy. Batteries that only report a capacity percentage
+// are weighted as an average battery, so that missing data never reads as an empty battery.
+func (s *System) Charge() float64 {
+	var known int
+	for _, b := range s.Batteries {
+		if _, full := energy(b); full > 0 {
+			known++
+		}
+	}
+	average := 1.0
+	if known > 0 {
+		average = float64(s.EnergyFull) / float64(known)
+	}
+	var charge, weights float64
+	for _, b := range s.Batteries {
+		weight := average
+		if _, full := energy(b); full > 0 {
+			weight = float64(full)
+		}
+		charge += weight * b.Charge()
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return charge / weights
+}
+
+// OnMains reports whether any external supply is online.
+func (s *System) OnMains() bool {
+	for _, supply := range s.Supplies {
+		if supply.Online {
+			return true
+		}
+	}
+	return false
+}
+
+func Load() (*System, error) {
+	bs, err := battery.LoadAll()
+	if err != nil {
+		return nil, xerrors.Errorf("load power: %w", err)
+	}
+	s := System{Batteries: bs}
+	for _, t := range []string{TypeMains, TypeUSB} {
+		dirs, err := battery.ListSupplies(t)
+		if err != nil {
+			return nil, xerrors.Errorf("load power: %w", err)
+		}
+		for _, dir := range dirs {
+			s.Supplies = append(s.Supplies, loadSupply(dir, t))
+		}
+	}
+	s.aggregate()
+	return &s, nil
+}
+
+// loadSupply treats a supply without a readable online attribute as offline.
+func loadSupply(dir string, t string) *Supply {
+	online, _ := ioutil.ReadFile(filepath.Join(dir, "online"))
+	return &Supply{
+		Name:   filepath.Base(dir),
+		Type:   t,
+		Online: strings.TrimSpace(string(online)) == "1",
+	}
+}
+
+func (s *System) aggregate() {
+	var charging, discharging, full int
+	for _, b := range s.Batteries {
+		now, capacity := energy(b)
+		s.EnergyNow += now
+		s.EnergyFull += capacity
+		w, _ := b.Power()
+		switch b.Status {
+		case "Charging":
+			s.Rate += int(w * 1e6)
+			charging++
+		case "Discharging":
+			s.Rate -= int(w * 1e6)
+			discharging++
+		case "Full":
+			full++
+		}
+	}
+	switch {
+	case charging > 0:
+		s.Status = "Charging"
+	case discharging > 0:
+		s.Status = "Discharging"
+	case full > 0 && full == len(s.Batteries):
+		s.Status = "Full"
+	case len(s.Batteries) > 0 && s.OnMains():
+		s.Status = "Not charging"
+	default:
+		s.Status = "Unknown"
+	}
+}
+
+// energy returns the current and full energy of b in µWh, converting from charge when needed.
+func energy(b *battery.B) (int, int) {
+	switch {
+	case b.EnergyFull > 0:
+		return b.EnergyNow, b.EnergyFull
+	case b.ChargeFull > 0 && b.VoltageNow > 0:
+		return int(int64(b.ChargeNow) * int64(b.VoltageNow) / 1e6), int(int64(b.ChargeFull) * int64(b.VoltageNow) / 1e6)
+	}
+	return 0, 0
+}
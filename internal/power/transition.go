@@ -0,0 +1,40 @@
+package power
+
+type Transition int
+
+const (
+	Plugged Transition = iota + 1
+	Unplugged
+)
+
+func (t Transition) String() string {
+	switch t {
+	case Plugged:
+		return "plugged"
+	case Unplugged:
+		return "unplugged"
+	}
+	return "unknown"
+}
+
+// Watcher detects plug and unplug transitions between successive system states.
+type Watcher struct {
+	initialized bool
+	onMains     bool
+}
+
+// Update returns the transition since the previous update, if any. The first update only records the state.
+func (w *Watcher) Update(s *System) (Transition, bool) {
+	onMains := s.OnMains()
+	defer func() {
+		w.initialized = true
+		w.onMains = onMains
+	}()
+	switch {
+	case !w.initialized || onMains == w.onMains:
+		return 0, false
+	case onMains:
+		return Plugged, true
+	}
+	return Unplugged, true
+}
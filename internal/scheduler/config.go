@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const configFilename = "jobs.json"
+
+type Config struct {
+	Jobs map[string]*JobConfig `json:"jobs"`
+}
+
+type JobConfig struct {
+	Interval string `json:"interval"`
+	Jitter   string `json:"jitter"`
+	Disabled bool   `json:"disabled"`
+}
+
+func ConfigPath() (string, error) {
+	return xdg.ConfigPath(configFilename)
+}
+
+// LoadConfig reads the jobs config file, returning an empty config when it does not exist.
+func LoadConfig() (*Config, error) {
+	f, err := xdg.ReadConfig(configFilename)
+	if err != nil {
+		return nil, xerrors.Errorf("load jobs config: %w", err)
+	}
+	if f == nil {
+		return &Config{}, nil
+	}
+	var config Config
+	if err := f.Decode(&config); err != nil {
+		return nil, xerrors.Errorf("load jobs config: %w", err)
+	}
+	names := make([]string, 0, len(config.Jobs))
+	for name := range config.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, _, err := config.Jobs[name].durations(); err != nil {
+			return nil, xerrors.Errorf("parse %s: jobs.%s: %w", f.Path, name, err)
+		}
+	}
+	return &config, nil
+}
+
+// Apply overrides the interval and jitter of job and reports whether the job is enabled.
+func (c *Config) Apply(job Job) (Job, bool) {
+	jc, ok := c.Jobs[job.Name]
+	if !ok {
+		return job, true
+	}
+	interval, jitter, err := jc.durations()
+	if err != nil {
+		return job, true
+	}
+	if interval > 0 {
+		job.Interval = interval
+	}
+	if jc.Jitter != "" {
+		job.Jitter = jitter
+	}
+	return job, !jc.Disabled
+}
+
+func (jc *JobConfig) durations() (time.Duration, time.Duration, error) {
+	var interval, jitter time.Duration
+	var err error
+	if jc.Interval != "" {
+		if interval, err = time.ParseDuration(jc.Interval); err != nil {
+			return 0, 0, xerrors.Errorf("interval: %w", err)
+		}
+		if interval <= 0 {
+			return 0, 0, xerrors.Errorf("interval: must be positive, got %v", interval)
+		}
+	}
+	if jc.Jitter != "" {
+		if jitter, err = time.ParseDuration(jc.Jitter); err != nil {
+			return 0, 0, xerrors.Errorf("jitter: %w", err)
+		}
+		if jitter < 0 {
+			return 0, 0, xerrors.Errorf("jitter: must not be negative, got %v", jitter)
+		}
+	}
+	return interval, jitter, nil
+}
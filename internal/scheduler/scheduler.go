@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+type Func func(ctx context.Context) error
+
+type Job struct {
+	Name string
+	// Interval between runs, zero for one-shot jobs.
+	Interval time.Duration
+	// Jitter is the maximum random delay added to each run.
+	Jitter time.Duration
+	Run    Func
+}
+
+type Status struct {
+	Name      string
+	Interval  time.Duration
+	Running   bool
+	LastRun   time.Time
+	NextRun   time.Time
+	LastError error
+}
+
+type Scheduler struct {
+	logger  *log.Logger
+	onError func(job string, err error)
+	mu      sync.Mutex
+	ctx     context.Context
+	jobs    map[string]*entry
+	wg      sync.WaitGroup
+}
+
+type entry struct {
+	job     Job
+	timer   *time.Timer
+	running bool
+	lastRun time.Time
+	nextRun time.Time
+	lastErr error
+}
+
+// New creates a scheduler. onError is called when a job fails with an error different from its previous run.
+func New(logger *log.Logger, onError func(job string, err error)) *Scheduler {
+	return &Scheduler{
+		logger:  logger,
+		onError: onError,
+		jobs:    make(map[string]*entry),
+	}
+}
+
+// Add schedules a periodic job. When a job with the same name is already scheduled, its interval,
+// jitter and function are updated while its status and any run in progress are kept.
+func (s *Scheduler) Add(job Job) error {
+	if job.Interval <= 0 {
+		return xerrors.Errorf("add job %s: non-positive interval: %v", job.Name, job.Interval)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[job.Name]
+	if !ok {
+		e = &entry{job: job}
+		s.jobs[job.Name] = e
+		s.resetLocked(e, job.Interval)
+		return nil
+	}
+	changed := e.job.Interval != job.Interval || e.job.Jitter != job.Jitter
+	e.job = job
+	// A run in progress reschedules with the new interval when it completes.
+	if changed && !e.running {
+		e.timer.Stop()
+		s.resetLocked(e, job.Interval)
+	}
+	return nil
+}
+
+// After schedules fn to run once after d, replacing any job with the same name.
+func (s *Scheduler) After(name string, d time.Duration, fn Func) {
+	s.schedule(Job{Name: name, Run: fn}, d)
+}
+
+// Cancel removes a job before its next run and reports whether it existed.
+func (s *Scheduler) Cancel(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return false
+	}
+	e.timer.Stop()
+	delete(s.jobs, name)
+	return true
+}
+
+func (s *Scheduler) schedule(job Job, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.jobs[job.Name]; ok {
+		prev.timer.Stop()
+	}
+	e := &entry{job: job}
+	s.jobs[job.Name] = e
+	s.resetLocked(e, d)
+}
+
+func (s *Scheduler) resetLocked(e *entry, d time.Duration) {
+	if e.job.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(e.job.Jitter)))
+	}
+	e.nextRun = time.Now().Add(d)
+	e.timer = time.AfterFunc(d, func() {
+		s.fire(e)
+	})
+}
+
+// Run executes jobs until the context is cancelled, then waits for running jobs to finish.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+	<-ctx.Done()
+	s.mu.Lock()
+	for _, e := range s.jobs {
+		e.timer.Stop()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Scheduler) fire(e *entry) {
+	s.mu.Lock()
+	if s.jobs[e.job.Name] != e {
+		s.mu.Unlock()
+		return
+	}
+	ctx := s.ctx
+	if ctx == nil || ctx.Err() != nil {
+		// Not running yet, try again on the next tick.
+		s.rescheduleLocked(e)
+		s.mu.Unlock()
+		return
+	}
+	if e.running {
+		s.logger.Printf("job %s: previous run still in progress, skipping", e.job.Name)
+		s.rescheduleLocked(e)
+		s.mu.Unlock()
+		return
+	}
+	e.running = true
+	e.lastRun = time.Now()
+	// Add may update the job while it runs.
+	job := e.job
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+	err := s.runJob(ctx, job)
+	s.mu.Lock()
+	e.running = false
+	changed := !sameError(err, e.lastErr)
+	e.lastErr = err
+	if e.job.Interval > 0 {
+		if s.jobs[e.job.Name] == e {
+			s.rescheduleLocked(e)
+		}
+	} else if s.jobs[e.job.Name] == e {
+		delete(s.jobs, e.job.Name)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Printf("job %s: %v", job.Name, err)
+		if changed && s.onError != nil {
+			s.onError(job.Name, err)
+		}
+	}
+}
+
+func (s *Scheduler) rescheduleLocked(e *entry) {
+	d := e.job.Interval
+	if d <= 0 {
+		d = time.Second
+	}
+	s.resetLocked(e, d)
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("panic: %+v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func sameError(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Error() == b.Error()
+}
+
+func (s *Scheduler) Status() []*Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*Status, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		result = append(result, &Status{
+			Name:      e.job.Name,
+			Interval:  e.job.Interval,
+			Running:   e.running,
+			LastRun:   e.lastRun,
+			NextRun:   e.nextRun,
+			LastError: e.lastErr,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
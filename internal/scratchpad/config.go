@@ -0,0 +1,210 @@
+package scratchpad
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const configFilename = "scratchpads.json"
+
+type Config struct {
+	Scratchpads []*ConfigEntry `json:"scratchpads"`
+}
+
+type ConfigEntry struct {
+	Key      string            `json:"key"`
+	Name     string            `json:"name"`
+	Command  []string          `json:"command"`
+	Class    string            `json:"class"`
+	Instance string            `json:"instance"`
+	Matchers []*ConfigMatcher  `json:"matchers"`
+	Group    string            `json:"group"`
+	Dir      string            `json:"dir"`
+	Env      map[string]string `json:"env"`
+	Geometry *ConfigGeometry   `json:"geometry"`
+}
+
+type ConfigMatcher struct {
+	Class    string `json:"class"`
+	Instance string `json:"instance"`
+	Title    string `json:"title"`
+}
+
+type ConfigGeometry struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Anchor string  `json:"anchor"`
+}
+
+type FieldError struct {
+	Index   int
+	Key     string
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("scratchpads[%d] (key %q): %s: %s", e.Index, e.Key, e.Field, e.Message)
+	}
+	return fmt.Sprintf("scratchpads[%d]: %s: %s", e.Index, e.Field, e.Message)
+}
+
+type ConfigError struct {
+	Path   string
+	Errors []*FieldError
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		msgs = append(msgs, fieldErr.Error())
+	}
+	return fmt.Sprintf("%s: %s", e.Path, strings.Join(msgs, "; "))
+}
+
+func ConfigPath() (string, error) {
+	return xdg.ConfigPath(configFilename)
+}
+
+// Load returns the built-in scratchpads overridden and extended by the entries in the config file.
+func Load() (map[string]*S, error) {
+	f, err := xdg.ReadConfig(configFilename)
+	if err != nil {
+		return nil, xerrors.Errorf("load scratchpads: %w", err)
+	}
+	if f == nil {
+		return All(), nil
+	}
+	config, err := ParseConfig(f.Path, f.Data)
+	if err != nil {
+		return nil, xerrors.Errorf("load scratchpads: %w", err)
+	}
+	result := All()
+	for key, s := range config {
+		result[key] = s
+	}
+	return result, nil
+}
+
+func ParseConfig(path string, data []byte) (map[string]*S, error) {
+	var config Config
+	if err := (&xdg.ConfigFile{Path: path, Data: data}).Decode(&config); err != nil {
+		return nil, err
+	}
+	result := make(map[string]*S, len(config.Scratchpads))
+	var errs []*FieldError
+	for i, e := range config.Scratchpads {
+		matchers, fieldErrs := e.validate(i)
+		if _, ok := result[e.Key]; ok && e.Key != "" {
+			fieldErrs = append(fieldErrs, &FieldError{Index: i, Key: e.Key, Field: "key", Message: "duplicate key"})
+		}
+		if len(fieldErrs) > 0 {
+			errs = append(errs, fieldErrs...)
+			continue
+		}
+		result[e.Key] = e.scratchpad(matchers)
+	}
+	if len(errs) > 0 {
+		return nil, &ConfigError{Path: path, Errors: errs}
+	}
+	return result, nil
+}
+
+// validate returns all field errors of the entry along with its compiled matchers.
+func (e *ConfigEntry) validate(i int) ([]*Matcher, []*FieldError) {
+	var errs []*FieldError
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Index: i, Key: e.Key, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	switch {
+	case e.Key == "":
+		fail("key", "must not be empty")
+	case strings.IndexFunc(e.Key, unicode.IsSpace) >= 0:
+		fail("key", "must not contain whitespace")
+	}
+	switch {
+	case e.Name == "":
+		fail("name", "must not be empty")
+	case strings.ContainsRune(e.Name, '/'):
+		fail("name", "must not contain '/'")
+	}
+	if len(e.Command) == 0 || e.Command[0] == "" {
+		fail("command", "must not be empty")
+	}
+	if e.Class == "" && e.Instance == "" && len(e.Matchers) == 0 {
+		fail("class", "class, instance or matchers must be set")
+	}
+	for key := range e.Env {
+		if key == "" || strings.Contains(key, "=") {
+			fail("env", "invalid variable name: %q", key)
+		}
+	}
+	if g := e.Geometry; g != nil {
+		if g.Width <= 0 || g.Width > 1 {
+			fail("geometry.width", "must be a fraction in (0, 1], got %v", g.Width)
+		}
+		if g.Height <= 0 || g.Height > 1 {
+			fail("geometry.height", "must be a fraction in (0, 1], got %v", g.Height)
+		}
+		if !Anchor(g.Anchor).IsValid() {
+			fail("geometry.anchor", "must be one of center, top, bottom, left or right, got %q", g.Anchor)
+		}
+	}
+	var matchers []*Matcher
+	for j, cm := range e.Matchers {
+		field := fmt.Sprintf("matchers[%d]", j)
+		if cm.Class == "" && cm.Instance == "" && cm.Title == "" {
+			fail(field, "must not be empty")
+			continue
+		}
+		var m Matcher
+		for _, f := range []struct {
+			name string
+			expr string
+			re   **regexp.Regexp
+		}{
+			{name: "class", expr: cm.Class, re: &m.Class},
+			{name: "instance", expr: cm.Instance, re: &m.Instance},
+			{name: "title", expr: cm.Title, re: &m.Title},
+		} {
+			if f.expr == "" {
+				continue
+			}
+			re, err := regexp.Compile(f.expr)
+			if err != nil {
+				fail(field+"."+f.name, "%v", err)
+				continue
+			}
+			*f.re = re
+		}
+		matchers = append(matchers, &m)
+	}
+	return matchers, errs
+}
+
+func (e *ConfigEntry) scratchpad(matchers []*Matcher) *S {
+	s := &S{
+		Name:         e.Name,
+		Cmd:          e.Command,
+		ClassName:    e.Class,
+		InstanceName: e.Instance,
+		Group:        e.Group,
+		Dir:          e.Dir,
+		Env:          e.Env,
+		Matchers:     matchers,
+	}
+	if e.Geometry != nil {
+		s.Geometry = &Geometry{
+			Width:  e.Geometry.Width,
+			Height: e.Geometry.Height,
+			Anchor: Anchor(e.Geometry.Anchor),
+		}
+	}
+	return s
+}
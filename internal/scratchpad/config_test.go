@@ -0,0 +1,129 @@
+package scratchpad
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestParseConfig(t *testing.T) {
+	sps, err := ParseConfig("scratchpads.json", []byte(`{
+		"scratchpads": [
+			{
+				"key": "m",
+				"name": "music",
+				"command": ["spotify"],
+				"matchers": [{"class": "^Spotify$"}],
+				"group": "media",
+				"dir": "~/music",
+				"env": {"LANG": "C"},
+				"geometry": {"width": 0.5, "height": 0.4, "anchor": "top"}
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, ok := sps["m"]
+	if !ok {
+		t.Fatalf("scratchpad m not parsed: %v", sps)
+	}
+	if sp.Name != "music" || !reflect.DeepEqual(sp.Cmd, []string{"spotify"}) || sp.Group != "media" || sp.Dir != "~/music" {
+		t.Errorf("unexpected scratchpad: %+v", sp)
+	}
+	if !reflect.DeepEqual(sp.Env, map[string]string{"LANG": "C"}) {
+		t.Errorf("unexpected env: %v", sp.Env)
+	}
+	if expected := (&Geometry{Width: 0.5, Height: 0.4, Anchor: AnchorTop}); !reflect.DeepEqual(sp.Geometry, expected) {
+		t.Errorf("geometry %+v, expected %+v", sp.Geometry, expected)
+	}
+	if len(sp.Matchers) != 1 || sp.Matchers[0].Class.String() != "^Spotify$" {
+		t.Errorf("unexpected matchers: %+v", sp.Matchers)
+	}
+}
+
+func TestParseConfig_UnknownField(t *testing.T) {
+	_, err := ParseConfig("scratchpads.json", []byte(`{"scratchpads": [{"key": "m", "nmae": "music"}]}`))
+	if err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	var configErr *ConfigError
+	if xerrors.As(err, &configErr) {
+		t.Errorf("expected a decode error, got %v", err)
+	}
+}
+
+func TestParseConfig_FieldErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name:     "empty entry",
+			config:   `{"scratchpads": [{}]}`,
+			expected: []string{"key", "name", "command", "class"},
+		},
+		{
+			name: "nothing to match",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "a", "command": ["a"]}
+			]}`,
+			expected: []string{"class"},
+		},
+		{
+			name: "duplicate key",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "a", "command": ["a"], "class": "A"},
+				{"key": "m", "name": "b", "command": ["b"], "class": "B"}
+			]}`,
+			expected: []string{"key"},
+		},
+		{
+			name: "invalid geometry",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "a", "command": ["a"], "class": "A",
+				 "geometry": {"width": 0, "height": 1.5, "anchor": "middle"}}
+			]}`,
+			expected: []string{"geometry.width", "geometry.height", "geometry.anchor"},
+		},
+		{
+			name: "invalid env",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "a", "command": ["a"], "class": "A", "env": {"A=B": "c"}}
+			]}`,
+			expected: []string{"env"},
+		},
+		{
+			name: "invalid matcher",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "a", "command": ["a"], "matchers": [{}, {"title": "("}]}
+			]}`,
+			expected: []string{"matchers[0]", "matchers[1].title"},
+		},
+		{
+			name: "invalid fields and matcher",
+			config: `{"scratchpads": [
+				{"key": "m", "name": "", "command": ["a"], "env": {"": "b"},
+				 "matchers": [{"class": "[", "instance": "(", "title": "^a$"}]}
+			]}`,
+			expected: []string{"name", "env", "matchers[0].class", "matchers[0].instance"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig("scratchpads.json", []byte(tt.config))
+			var configErr *ConfigError
+			if !xerrors.As(err, &configErr) {
+				t.Fatalf("expected a config error, got %v", err)
+			}
+			fields := make([]string, 0, len(configErr.Errors))
+			for _, fieldErr := range configErr.Errors {
+				fields = append(fields, fieldErr.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("fields %q, expected %q", fields, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package scratchpad
+
+import (
+	"context"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"golang.org/x/xerrors"
+)
+
+type Anchor string
+
+const (
+	AnchorCenter Anchor = "center"
+	AnchorTop    Anchor = "top"
+	AnchorBottom Anchor = "bottom"
+	AnchorLeft   Anchor = "left"
+	AnchorRight  Anchor = "right"
+)
+
+type Geometry struct {
+	Width  float64
+	Height float64
+	Anchor Anchor
+}
+
+var defaultGeometry = Geometry{Width: 0.6, Height: 0.6, Anchor: AnchorCenter}
+
+func (a Anchor) IsValid() bool {
+	switch a {
+	case "", AnchorCenter, AnchorTop, AnchorBottom, AnchorLeft, AnchorRight:
+		return true
+	}
+	return false
+}
+
+func (s *S) Show(ctx context.Context, c *bspc.Client, n *wm.Node, m *wm.Monitor) error {
+	target := s.geometry().Rectangle(m)
+	current := n.Client.FloatingRectangle
+	if _, err := c.Exec(ctx, bspc.Node(bspc.NodeID(n.ID)).
+		State(bspc.StateFloating).
+		Move(target.X-current.X, target.Y-current.Y).
+		Resize(bspc.HandleBottomRight, target.Width-current.Width, target.Height-current.Height).
+		Flag(bspc.Hidden, false).
+		Focus(),
+	); err != nil {
+		return xerrors.Errorf("show scratchpad: %w", err)
+	}
+	return nil
+}
+
+func (s *S) geometry() Geometry {
+	if s.Geometry == nil {
+		return defaultGeometry
+	}
+	return *s.Geometry
+}
+
+func (g Geometry) Rectangle(m *wm.Monitor) wm.Rectangle {
+	area := wm.Rectangle{
+		X:      m.Rectangle.X + m.Padding.Left,
+		Y:      m.Rectangle.Y + m.Padding.Top,
+		Width:  m.Rectangle.Width - m.Padding.Left - m.Padding.Right,
+		Height: m.Rectangle.Height - m.Padding.Top - m.Padding.Bottom,
+	}
+	r := wm.Rectangle{
+		Width:  int(float64(area.Width) * g.Width),
+		Height: int(float64(area.Height) * g.Height),
+	}
+	r.X = area.X + (area.Width-r.Width)/2
+	r.Y = area.Y + (area.Height-r.Height)/2
+	switch g.Anchor {
+	case AnchorTop:
+		r.Y = area.Y
+	case AnchorBottom:
+		r.Y = area.Y + area.Height - r.Height
+	case AnchorLeft:
+		r.X = area.X
+	case AnchorRight:
+		r.X = area.X + area.Width - r.Width
+	}
+	return r
+}
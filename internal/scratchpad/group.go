@@ -0,0 +1,114 @@
+package scratchpad
+
+import (
+	"context"
+	"sort"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"golang.org/x/xerrors"
+)
+
+func Toggle(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, key string) error {
+	launch, err := PrepareToggle(ctx, c, state, sps, key)
+	if err != nil || launch == nil {
+		return err
+	}
+	if err := launch.Launch(ctx, c); err != nil {
+		return xerrors.Errorf("toggle scratchpad %s: %w", key, err)
+	}
+	return nil
+}
+
+// PrepareToggle toggles a running scratchpad. A scratchpad that is not running is returned for the caller
+// to launch instead, since launching waits for the window and must not hold up other requests.
+func PrepareToggle(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, key string) (*S, error) {
+	sp, ok := sps[key]
+	if !ok {
+		return nil, xerrors.Errorf("toggle scratchpad: no such scratchpad: %s", key)
+	}
+	searchResult, ok := sp.SearchState(state)
+	if ok {
+		if err := searchResult.Toggle(ctx, c, state, sps); err != nil {
+			return nil, xerrors.Errorf("toggle scratchpad %s: %w", key, err)
+		}
+		return nil, nil
+	}
+	if err := hideGroupPeers(ctx, c, state, sps, sp); err != nil {
+		return nil, xerrors.Errorf("toggle scratchpad %s: %w", key, err)
+	}
+	return sp, nil
+}
+
+func ToggleGroup(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, group string) error {
+	launch, err := PrepareToggleGroup(ctx, c, state, sps, group)
+	if err != nil || launch == nil {
+		return err
+	}
+	if err := launch.Launch(ctx, c); err != nil {
+		return xerrors.Errorf("toggle scratchpad group %s: %w", group, err)
+	}
+	return nil
+}
+
+// PrepareToggleGroup is PrepareToggle for the next member of a group.
+func PrepareToggleGroup(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, group string) (*S, error) {
+	keys := GroupMembers(sps, group)
+	if len(keys) == 0 {
+		return nil, xerrors.Errorf("toggle scratchpad group: no such group: %s", group)
+	}
+	next := 0
+	for i, key := range keys {
+		if searchResult, ok := sps[key].SearchState(state); ok && searchResult.IsVisible(state) {
+			next = (i + 1) % len(keys)
+			break
+		}
+	}
+	sp := sps[keys[next]]
+	if err := hideGroupPeers(ctx, c, state, sps, sp); err != nil {
+		return nil, xerrors.Errorf("toggle scratchpad group %s: %w", group, err)
+	}
+	searchResult, ok := sp.SearchState(state)
+	if !ok {
+		return sp, nil
+	}
+	if err := searchResult.Show(ctx, c, state); err != nil {
+		return nil, xerrors.Errorf("toggle scratchpad group %s: %w", group, err)
+	}
+	return nil, nil
+}
+
+func GroupMembers(sps map[string]*S, group string) []string {
+	var keys []string
+	for key, sp := range sps {
+		if group != "" && sp.Group == group {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func show(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, searchResult *SearchResult) error {
+	if err := hideGroupPeers(ctx, c, state, sps, searchResult.Scratchpad); err != nil {
+		return err
+	}
+	return searchResult.Show(ctx, c, state)
+}
+
+func hideGroupPeers(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, sp *S) error {
+	for _, key := range GroupMembers(sps, sp.Group) {
+		peer := sps[key]
+		if peer == sp {
+			continue
+		}
+		searchResult, ok := peer.SearchState(state)
+		if !ok || !searchResult.IsVisible(state) {
+			continue
+		}
+		if err := searchResult.Hide(ctx, c); err != nil {
+			return xerrors.Errorf("hide group peer %s: %w", key, err)
+		}
+	}
+	return nil
+}
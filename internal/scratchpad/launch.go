@@ -0,0 +1,75 @@
+package scratchpad
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/lockfile"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const launchTimeout = 15 * time.Second
+
+var ErrLaunchInProgress = xerrors.New("launch in progress")
+
+// Launch starts the scratchpad and waits for its window to appear before showing it.
+func (s *S) Launch(ctx context.Context, c *bspc.Client) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+	}
+	defer unlock()
+	ctx, cancel := context.WithTimeout(ctx, launchTimeout)
+	defer cancel()
+	sub, err := c.Subscribe(ctx, "node_add")
+	if err != nil {
+		return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+	}
+	if err := s.Start(); err != nil {
+		return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+	}
+	pred := s.Predicate()
+	for e := range sub.Events() {
+		nodeAdd, ok := e.(*bspc.NodeAdd)
+		if !ok {
+			continue
+		}
+		n, err := wm.QueryNode(ctx, c, bspc.NodeID(nodeAdd.NodeID))
+		if err != nil {
+			return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+		}
+		if n == nil || !pred(n) {
+			continue
+		}
+		m, err := wm.QueryMonitor(ctx, c, bspc.MonitorDesc(bspc.Focused))
+		if err != nil {
+			return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+		}
+		if err := s.Show(ctx, c, n, m); err != nil {
+			return xerrors.Errorf("launch scratchpad %s: %w", s.Name, err)
+		}
+		return nil
+	}
+	return xerrors.Errorf("launch scratchpad %s: wait for window: %w", s.Name, sub.Err())
+}
+
+func (s *S) lock() (unlock func(), err error) {
+	runtimeDir, err := xdg.RuntimeDir()
+	if err != nil {
+		return nil, xerrors.Errorf("lock: %w", err)
+	}
+	l, err := lockfile.Acquire(filepath.Join(runtimeDir, "bspwmrc", "scratchpad-"+s.Name+".lock"))
+	if err != nil {
+		if xerrors.Is(err, lockfile.ErrLocked) {
+			return nil, ErrLaunchInProgress
+		}
+		return nil, xerrors.Errorf("lock: %w", err)
+	}
+	return func() {
+		_ = l.Release()
+	}, nil
+}
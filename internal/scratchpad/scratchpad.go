@@ -1,9 +1,13 @@
 package scratchpad
 
 import (
+	"context"
+	"os"
 	"os/exec"
 	"os/user"
-	"strconv"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/odsod/bspwmrc/internal/bspc"
 	"github.com/odsod/bspwmrc/internal/wm"
@@ -40,10 +44,13 @@ func All() map[string]*S {
 			InstanceName: "keepassxc",
 		},
 		"n": {
-			Name:         "spotify",
-			Cmd:          []string{"spotify"},
-			ClassName:    "",
-			InstanceName: "",
+			Name: "spotify",
+			Cmd:  []string{"spotify"},
+			Matchers: []*Matcher{
+				{Class: regexp.MustCompile(`^Spotify$`)},
+				// Until WM_CLASS is set, the window is only recognizable by its startup title.
+				{Class: regexp.MustCompile(`^$`), Instance: regexp.MustCompile(`^$`), Title: regexp.MustCompile(`^Spotify`)},
+			},
 		},
 		"s": {
 			Name:         "slack",
@@ -60,6 +67,10 @@ func All() map[string]*S {
 			},
 			ClassName:    "Google-chrome",
 			InstanceName: "mail.google.com",
+			Group:        "google",
+			Matchers: []*Matcher{
+				{Class: regexp.MustCompile(`^Google-chrome$`), Instance: regexp.MustCompile(`^mail\.google\.com`)},
+			},
 		},
 		"c": {
 			Name: "calendar",
@@ -70,6 +81,10 @@ func All() map[string]*S {
 			},
 			ClassName:    "Google-chrome",
 			InstanceName: "calendar.google.com",
+			Group:        "google",
+			Matchers: []*Matcher{
+				{Class: regexp.MustCompile(`^Google-chrome$`), Instance: regexp.MustCompile(`^calendar\.google\.com`)},
+			},
 		},
 		"r": {
 			Name: "drive",
@@ -80,6 +95,10 @@ func All() map[string]*S {
 			},
 			ClassName:    "Google-chrome",
 			InstanceName: "drive.google.com",
+			Group:        "google",
+			Matchers: []*Matcher{
+				{Class: regexp.MustCompile(`^Google-chrome$`), Instance: regexp.MustCompile(`^drive\.google\.com`)},
+			},
 		},
 		"l": {
 			Name: "meet",
@@ -90,6 +109,10 @@ func All() map[string]*S {
 			},
 			ClassName:    "Google-chrome",
 			InstanceName: "meet.google.com",
+			Group:        "google",
+			Matchers: []*Matcher{
+				{Class: regexp.MustCompile(`^Google-chrome$`), Instance: regexp.MustCompile(`^meet\.google\.com`)},
+			},
 		},
 	}
 }
@@ -99,36 +122,77 @@ type S struct {
 	Cmd          []string
 	ClassName    string
 	InstanceName string
+	Matchers     []*Matcher
+	Group        string
+	Dir          string
+	Env          map[string]string
+	Geometry     *Geometry
+}
+
+type Matcher struct {
+	Class    *regexp.Regexp
+	Instance *regexp.Regexp
+	Title    *regexp.Regexp
 }
 
 type SearchResult struct {
-	Node    *wm.Node
-	Desktop *wm.Desktop
-	Monitor *wm.Monitor
+	Scratchpad *S
+	Node       *wm.Node
+	Desktop    *wm.Desktop
+	Monitor    *wm.Monitor
 }
 
-func (s *SearchResult) Toggle(state *wm.State) error {
+// Toggle hides the scratchpad when it is focused, and otherwise shows it in place of any visible member
+// of its group in sps.
+func (s *SearchResult) Toggle(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S) error {
 	if s.IsFocused(state) {
-		if _, err := bspc.Run("node", strconv.Itoa(s.Node.ID), "--flag", "hidden=on"); err != nil {
+		if err := s.Hide(ctx, c); err != nil {
 			return xerrors.Errorf("toggle scratchpad: %w", err)
 		}
 		return nil
 	}
+	if err := show(ctx, c, state, sps, s); err != nil {
+		return xerrors.Errorf("toggle scratchpad: %w", err)
+	}
+	return nil
+}
+
+func (s *SearchResult) Hide(ctx context.Context, c *bspc.Client) error {
+	if _, err := c.Exec(ctx, bspc.Node(bspc.NodeID(s.Node.ID)).Flag(bspc.Hidden, true)); err != nil {
+		return xerrors.Errorf("hide scratchpad: %w", err)
+	}
+	return nil
+}
+
+func (s *SearchResult) Show(ctx context.Context, c *bspc.Client, state *wm.State) error {
+	focusedMonitor, err := state.FocusedMonitor()
+	if err != nil {
+		return xerrors.Errorf("show scratchpad: %w", err)
+	}
 	focusedDesktop, err := state.FocusedDesktop()
 	if err != nil {
-		return xerrors.Errorf("toggle scratchpad: %w", err)
+		return xerrors.Errorf("show scratchpad: %w", err)
 	}
+	n := s.Node
 	if s.Desktop.ID != focusedDesktop.ID {
-		if _, err := bspc.Run("node", strconv.Itoa(s.Node.ID), "--to-desktop", strconv.Itoa(focusedDesktop.ID)); err != nil {
-			return xerrors.Errorf("toggle scratchpad: %w", err)
+		if _, err := c.Exec(ctx, bspc.Node(bspc.NodeID(s.Node.ID)).ToDesktop(bspc.DesktopID(focusedDesktop.ID))); err != nil {
+			return xerrors.Errorf("show scratchpad: %w", err)
+		}
+		// bspwm translates the floating rectangle when moving across monitors.
+		if n, err = wm.QueryNode(ctx, c, bspc.NodeID(s.Node.ID)); err != nil {
+			return xerrors.Errorf("show scratchpad: %w", err)
 		}
 	}
-	if _, err := bspc.Run("node", strconv.Itoa(s.Node.ID), "--flag", "hidden=off", "--focus"); err != nil {
-		return xerrors.Errorf("toggle scratchpad: %w", err)
+	if err := s.Scratchpad.Show(ctx, c, n, focusedMonitor); err != nil {
+		return xerrors.Errorf("show scratchpad: %w", err)
 	}
 	return nil
 }
 
+func (s *SearchResult) IsVisible(state *wm.State) bool {
+	return s.Monitor.ID == state.FocusedMonitorID && s.Monitor.FocusedDesktopID == s.Desktop.ID && !s.Node.Hidden
+}
+
 func (s *SearchResult) IsFocused(state *wm.State) bool {
 	if state.FocusedMonitorID != s.Monitor.ID {
 		return false
@@ -152,6 +216,15 @@ func (s *S) Start() error {
 		return xerrors.Errorf("start scratchpad: %w", err)
 	}
 	cmd.Dir = currentUser.HomeDir
+	if s.Dir != "" {
+		cmd.Dir = expandHome(s.Dir, currentUser.HomeDir)
+	}
+	if len(s.Env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range s.Env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
 	if err := cmd.Start(); err != nil {
 		return xerrors.Errorf("start scratchpad: %w", err)
 	}
@@ -161,35 +234,66 @@ func (s *S) Start() error {
 	return nil
 }
 
-func (s *S) SearchState(state *wm.State) (*SearchResult, bool) {
-	for _, m := range state.Monitors {
-		for _, d := range m.Desktops {
-			if n, ok := s.SearchNode(d.Root); ok {
-				return &SearchResult{
-					Node:    n,
-					Desktop: d,
-					Monitor: m,
-				}, true
-			}
-		}
+func expandHome(dir string, homeDir string) string {
+	switch {
+	case dir == "~":
+		return homeDir
+	case strings.HasPrefix(dir, "~/"):
+		return filepath.Join(homeDir, dir[2:])
+	case !filepath.IsAbs(dir):
+		return filepath.Join(homeDir, dir)
 	}
-	return nil, false
+	return dir
 }
 
-func (s *S) SearchNode(root *wm.Node) (*wm.Node, bool) {
-	if root == nil {
-		return nil, false
+func (s *S) Predicate() wm.Predicate {
+	if len(s.Matchers) == 0 {
+		return wm.And(wm.IsClient, wm.ByClass(s.ClassName), wm.ByInstance(s.InstanceName))
 	}
-	if root.Client != nil {
-		if root.Client.ClassName == s.ClassName && root.Client.InstanceName == s.InstanceName {
-			return root, true
-		}
+	preds := make([]wm.Predicate, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		preds = append(preds, m.Predicate())
 	}
-	if child, ok := s.SearchNode(root.FirstChild); ok {
-		return child, true
+	return wm.Or(preds...)
+}
+
+func (m *Matcher) Predicate() wm.Predicate {
+	return func(n *wm.Node) bool {
+		if n.Client == nil {
+			return false
+		}
+		className, instanceName := n.Client.ClassName, n.Client.InstanceName
+		// Windows such as Spotify set WM_CLASS after mapping, so bspwm records them without a class.
+		if className == "" && instanceName == "" && (m.Class != nil || m.Instance != nil) {
+			if i, c, err := n.WMClass(); err == nil {
+				instanceName, className = i, c
+			}
+		}
+		if m.Class != nil && !m.Class.MatchString(className) {
+			return false
+		}
+		if m.Instance != nil && !m.Instance.MatchString(instanceName) {
+			return false
+		}
+		if m.Title != nil {
+			title, err := n.Title()
+			if err != nil || !m.Title.MatchString(title) {
+				return false
+			}
+		}
+		return true
 	}
-	if child, ok := s.SearchNode(root.SecondChild); ok {
-		return child, true
+}
+
+func (s *S) SearchState(state *wm.State) (*SearchResult, bool) {
+	l, ok := state.FindNode(s.Predicate())
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	return &SearchResult{
+		Scratchpad: s,
+		Node:       l.Node,
+		Desktop:    l.Desktop,
+		Monitor:    l.Monitor,
+	}, true
 }
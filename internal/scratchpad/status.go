@@ -0,0 +1,111 @@
+package scratchpad
+
+import (
+	"context"
+	"sort"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"golang.org/x/xerrors"
+)
+
+type State string
+
+const (
+	StateStopped State = "stopped"
+	StateHidden  State = "hidden"
+	StateVisible State = "visible"
+	// StateBackground is a shown scratchpad on a desktop that is not active on its monitor.
+	StateBackground State = "background"
+)
+
+var ErrNotRunning = xerrors.New("not running")
+
+type Status struct {
+	Key          string
+	Scratchpad   *S
+	State        State
+	SearchResult *SearchResult
+}
+
+func StatusAll(state *wm.State, sps map[string]*S) []*Status {
+	keys := make([]string, 0, len(sps))
+	for key := range sps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*Status, 0, len(keys))
+	for _, key := range keys {
+		status := &Status{Key: key, Scratchpad: sps[key], State: StateStopped}
+		if searchResult, ok := sps[key].SearchState(state); ok {
+			status.SearchResult = searchResult
+			switch {
+			case searchResult.Node.Hidden:
+				status.State = StateHidden
+			case searchResult.Monitor.FocusedDesktopID == searchResult.Desktop.ID:
+				status.State = StateVisible
+			default:
+				status.State = StateBackground
+			}
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+func HideAll(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S) (int, error) {
+	hidden := make(map[int]bool)
+	for _, status := range StatusAll(state, sps) {
+		if status.SearchResult == nil || status.SearchResult.Node.Hidden || hidden[status.SearchResult.Node.ID] {
+			continue
+		}
+		if err := status.SearchResult.Hide(ctx, c); err != nil {
+			return len(hidden), xerrors.Errorf("hide all scratchpads: %w", err)
+		}
+		hidden[status.SearchResult.Node.ID] = true
+	}
+	return len(hidden), nil
+}
+
+// ShowAll shows every running scratchpad that is not visible, but at most one member of each group.
+func ShowAll(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S) (int, error) {
+	statuses := StatusAll(state, sps)
+	groups := make(map[string]bool)
+	for _, status := range statuses {
+		if status.SearchResult != nil && status.SearchResult.IsVisible(state) && status.Scratchpad.Group != "" {
+			groups[status.Scratchpad.Group] = true
+		}
+	}
+	shown := make(map[int]bool)
+	for _, status := range statuses {
+		if status.SearchResult == nil || status.SearchResult.IsVisible(state) || shown[status.SearchResult.Node.ID] {
+			continue
+		}
+		if group := status.Scratchpad.Group; group != "" {
+			if groups[group] {
+				continue
+			}
+			groups[group] = true
+		}
+		if err := status.SearchResult.Show(ctx, c, state); err != nil {
+			return len(shown), xerrors.Errorf("show all scratchpads: %w", err)
+		}
+		shown[status.SearchResult.Node.ID] = true
+	}
+	return len(shown), nil
+}
+
+func Kill(ctx context.Context, c *bspc.Client, state *wm.State, sps map[string]*S, key string) error {
+	sp, ok := sps[key]
+	if !ok {
+		return xerrors.Errorf("kill scratchpad: no such scratchpad: %s", key)
+	}
+	searchResult, ok := sp.SearchState(state)
+	if !ok {
+		return xerrors.Errorf("kill scratchpad %s: %w", key, ErrNotRunning)
+	}
+	if _, err := c.Exec(ctx, bspc.Node(bspc.NodeID(searchResult.Node.ID)).Kill()); err != nil {
+		return xerrors.Errorf("kill scratchpad %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package scratchpad
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/bspc/bspctest"
+	"github.com/odsod/bspwmrc/internal/wm"
+)
+
+const (
+	testMonitorID      = 1
+	testFocusedDesktop = 2
+	testOtherDesktop   = 3
+)
+
+func testClient(t *testing.T) (*bspctest.Server, *bspc.Client) {
+	s := bspctest.NewServer()
+	c, err := bspc.NewClient(bspc.WithSocketPath(s.SocketPath))
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	return s, c
+}
+
+func testNode(id int, className, instanceName string, hidden bool) *wm.Node {
+	return &wm.Node{
+		ID:     id,
+		Hidden: hidden,
+		Client: &wm.Client{
+			ClassName:         className,
+			InstanceName:      instanceName,
+			State:             "floating",
+			FloatingRectangle: wm.Rectangle{X: 10, Y: 10, Width: 100, Height: 100},
+		},
+	}
+}
+
+// testState returns a single monitor with a focused and an unfocused desktop holding the given roots.
+func testState(focused, other *wm.Node) *wm.State {
+	desktop := func(id int, root *wm.Node) *wm.Desktop {
+		d := &wm.Desktop{ID: id, Name: "desktop", Root: root}
+		if root != nil {
+			d.FocusedNodeID = root.ID
+		}
+		return d
+	}
+	return &wm.State{
+		FocusedMonitorID: testMonitorID,
+		Monitors: []*wm.Monitor{
+			{
+				ID:               testMonitorID,
+				Name:             "monitor",
+				FocusedDesktopID: testFocusedDesktop,
+				Rectangle:        wm.Rectangle{Width: 1000, Height: 800},
+				Desktops: []*wm.Desktop{
+					desktop(testFocusedDesktop, focused),
+					desktop(testOtherDesktop, other),
+				},
+			},
+		},
+	}
+}
+
+func mustArgs(t *testing.T, cmd bspc.Command) []string {
+	args, err := cmd.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return args
+}
+
+func showArgs(t *testing.T, sp *S, n *wm.Node, m *wm.Monitor) []string {
+	target := sp.geometry().Rectangle(m)
+	current := n.Client.FloatingRectangle
+	return mustArgs(t, bspc.Node(bspc.NodeID(n.ID)).
+		State(bspc.StateFloating).
+		Move(target.X-current.X, target.Y-current.Y).
+		Resize(bspc.HandleBottomRight, target.Width-current.Width, target.Height-current.Height).
+		Flag(bspc.Hidden, false).
+		Focus())
+}
+
+func assertCommands(t *testing.T, s *bspctest.Server, expected ...[]string) {
+	t.Helper()
+	if actual := s.Commands(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("commands:\n%q\nexpected:\n%q", actual, expected)
+	}
+}
+
+func TestSearchResult_Toggle_Hide(t *testing.T) {
+	s, c := testClient(t)
+	defer s.Close()
+	sps := map[string]*S{"u": {Name: "terminal", ClassName: "URxvt", InstanceName: "scratchpad"}}
+	state := testState(testNode(5, "URxvt", "scratchpad", false), nil)
+	searchResult, ok := sps["u"].SearchState(state)
+	if !ok {
+		t.Fatal("scratchpad not found")
+	}
+	if err := searchResult.Toggle(context.Background(), c, state, sps); err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, s, mustArgs(t, bspc.Node(bspc.NodeID(5)).Flag(bspc.Hidden, true)))
+}
+
+func TestSearchResult_Toggle_Show(t *testing.T) {
+	s, c := testClient(t)
+	defer s.Close()
+	sps := map[string]*S{"u": {Name: "terminal", ClassName: "URxvt", InstanceName: "scratchpad"}}
+	n := testNode(5, "URxvt", "scratchpad", true)
+	state := testState(nil, n)
+	s.ReplyJSON([]string{"query", "-T", "-n", "5"}, n)
+	searchResult, ok := sps["u"].SearchState(state)
+	if !ok {
+		t.Fatal("scratchpad not found")
+	}
+	if err := searchResult.Toggle(context.Background(), c, state, sps); err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(
+		t,
+		s,
+		mustArgs(t, bspc.Node(bspc.NodeID(5)).ToDesktop(bspc.DesktopID(testFocusedDesktop))),
+		[]string{"query", "-T", "-n", "5"},
+		showArgs(t, sps["u"], n, state.Monitors[0]),
+	)
+}
+
+func TestSearchResult_Toggle_HidesGroupPeer(t *testing.T) {
+	s, c := testClient(t)
+	defer s.Close()
+	sps := map[string]*S{
+		"m": {Name: "mail", ClassName: "Google-chrome", InstanceName: "mail", Group: "google"},
+		"c": {Name: "calendar", ClassName: "Google-chrome", InstanceName: "calendar", Group: "google"},
+	}
+	mail := testNode(5, "Google-chrome", "mail", false)
+	calendar := testNode(6, "Google-chrome", "calendar", true)
+	state := testState(mail, calendar)
+	s.ReplyJSON([]string{"query", "-T", "-n", "6"}, calendar)
+	searchResult, ok := sps["c"].SearchState(state)
+	if !ok {
+		t.Fatal("scratchpad not found")
+	}
+	if err := searchResult.Toggle(context.Background(), c, state, sps); err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(
+		t,
+		s,
+		mustArgs(t, bspc.Node(bspc.NodeID(5)).Flag(bspc.Hidden, true)),
+		mustArgs(t, bspc.Node(bspc.NodeID(6)).ToDesktop(bspc.DesktopID(testFocusedDesktop))),
+		[]string{"query", "-T", "-n", "6"},
+		showArgs(t, sps["c"], calendar, state.Monitors[0]),
+	)
+}
+
+func TestToggle_Launch(t *testing.T) {
+	runtimeDir, err := ioutil.TempDir("", "scratchpad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(runtimeDir)
+	restoreEnv := os.Getenv("XDG_RUNTIME_DIR")
+	if err := os.Setenv("XDG_RUNTIME_DIR", runtimeDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("XDG_RUNTIME_DIR", restoreEnv)
+	s, c := testClient(t)
+	defer s.Close()
+	sps := map[string]*S{"u": {Name: "terminal", Cmd: []string{"true"}, ClassName: "URxvt", InstanceName: "scratchpad"}}
+	state := testState(nil, nil)
+	n := testNode(7, "URxvt", "scratchpad", false)
+	s.ReplyJSON([]string{"query", "-T", "-n", "7"}, n)
+	s.ReplyJSON([]string{"query", "-T", "-m", "focused"}, state.Monitors[0])
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- Toggle(ctx, c, state, sps, "u")
+	}()
+	if err := s.WaitSubscriber(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Emit("node_add 0x1 0x3 0x0 0x7")
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(
+		t,
+		s,
+		[]string{"subscribe", "node_add"},
+		[]string{"query", "-T", "-n", "7"},
+		[]string{"query", "-T", "-m", "focused"},
+		showArgs(t, sps["u"], n, state.Monitors[0]),
+	)
+}
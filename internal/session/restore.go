@@ -0,0 +1,343 @@
+package session
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"os/user"
+
+	"github.com/odsod/bspwmrc/internal/bspc"
+	"github.com/odsod/bspwmrc/internal/wm"
+	"golang.org/x/xerrors"
+)
+
+const stagingDesktopName = "bspwmrc-restore"
+
+type placement struct {
+	receptacleID int
+	desktopID    int
+	client       *Client
+}
+
+type restorer struct {
+	logger    *log.Logger
+	c         *bspc.Client
+	stagingID int
+	focusedID int
+	// saved are the session desktops, whose remaining windows are never pulled into receptacles.
+	saved        map[int]bool
+	origins      map[int]int
+	placements   []*placement
+	placedWindow map[int]bool
+}
+
+func Restore(ctx context.Context, logger *log.Logger, c *bspc.Client, s *Session) (err error) {
+	r := &restorer{
+		logger:       logger,
+		c:            c,
+		saved:        make(map[int]bool),
+		origins:      make(map[int]int),
+		placedWindow: make(map[int]bool),
+	}
+	targets, err := r.ensureDesktops(ctx, s)
+	if err != nil {
+		return xerrors.Errorf("restore session: %w", err)
+	}
+	if err := r.createStagingDesktop(ctx); err != nil {
+		return xerrors.Errorf("restore session: %w", err)
+	}
+	defer func() {
+		if errCleanup := r.removeStagingDesktop(ctx); errCleanup != nil && err == nil {
+			err = xerrors.Errorf("restore session: %w", errCleanup)
+		}
+		if _, errFocus := r.c.Exec(ctx, bspc.Desktop(bspc.DesktopID(r.focusedID)).Focus()); errFocus != nil && err == nil {
+			err = xerrors.Errorf("restore session: %w", errFocus)
+		}
+	}()
+	for _, t := range targets {
+		r.saved[t.desktopID] = true
+	}
+	for _, t := range targets {
+		if err := r.restoreDesktop(ctx, t.desktopID, t.desktop); err != nil {
+			return xerrors.Errorf("restore session: %w", err)
+		}
+	}
+	if err := r.fillReceptacles(ctx); err != nil {
+		return xerrors.Errorf("restore session: %w", err)
+	}
+	return nil
+}
+
+type target struct {
+	desktopID int
+	desktop   *Desktop
+}
+
+func (r *restorer) ensureDesktops(ctx context.Context, s *Session) ([]*target, error) {
+	state, err := wm.Load(ctx, r.c)
+	if err != nil {
+		return nil, xerrors.Errorf("ensure desktops: %w", err)
+	}
+	focusedMonitor, err := state.FocusedMonitor()
+	if err != nil {
+		return nil, xerrors.Errorf("ensure desktops: %w", err)
+	}
+	focusedDesktop, err := state.FocusedDesktop()
+	if err != nil {
+		return nil, xerrors.Errorf("ensure desktops: %w", err)
+	}
+	r.focusedID = focusedDesktop.ID
+	var missing bool
+	for _, sm := range s.Monitors {
+		m, ok := state.MonitorByName(sm.Name)
+		if !ok {
+			m = focusedMonitor
+		}
+		for _, sd := range sm.Desktops {
+			if _, _, ok := state.DesktopByName(sd.Name); ok {
+				continue
+			}
+			r.logger.Printf("adding desktop %s to monitor %s", sd.Name, m.Name)
+			if _, err := r.c.Exec(ctx, bspc.Monitor(bspc.MonitorID(m.ID)).AddDesktops(sd.Name)); err != nil {
+				return nil, xerrors.Errorf("ensure desktops: %w", err)
+			}
+			missing = true
+		}
+	}
+	if missing {
+		if state, err = wm.Load(ctx, r.c); err != nil {
+			return nil, xerrors.Errorf("ensure desktops: %w", err)
+		}
+	}
+	var result []*target
+	for _, sm := range s.Monitors {
+		for _, sd := range sm.Desktops {
+			d, _, ok := state.DesktopByName(sd.Name)
+			if !ok {
+				return nil, xerrors.Errorf("ensure desktops: no desktop named %s", sd.Name)
+			}
+			result = append(result, &target{desktopID: d.ID, desktop: sd})
+		}
+	}
+	return result, nil
+}
+
+func (r *restorer) createStagingDesktop(ctx context.Context) error {
+	if _, err := r.c.Exec(ctx, bspc.Monitor(bspc.MonitorDesc(bspc.Focused)).AddDesktops(stagingDesktopName)); err != nil {
+		return xerrors.Errorf("create staging desktop: %w", err)
+	}
+	ids, err := r.c.QueryDesktops(ctx, bspc.DesktopName(stagingDesktopName))
+	if err != nil {
+		return xerrors.Errorf("create staging desktop: %w", err)
+	}
+	if len(ids) == 0 {
+		return xerrors.New("create staging desktop: not found after creation")
+	}
+	r.stagingID = ids[0]
+	return nil
+}
+
+func (r *restorer) removeStagingDesktop(ctx context.Context) error {
+	state, err := wm.Load(ctx, r.c)
+	if err != nil {
+		return xerrors.Errorf("remove staging desktop: %w", err)
+	}
+	for _, l := range state.Clients() {
+		if l.Desktop.ID != r.stagingID {
+			continue
+		}
+		origin, ok := r.origins[l.Node.ID]
+		if !ok {
+			continue
+		}
+		if _, err := r.c.Exec(ctx, bspc.Node(bspc.NodeID(l.Node.ID)).ToDesktop(bspc.DesktopID(origin))); err != nil {
+			return xerrors.Errorf("remove staging desktop: %w", err)
+		}
+	}
+	if _, err := r.c.Exec(ctx, bspc.Desktop(bspc.DesktopID(r.stagingID)).Remove()); err != nil {
+		return xerrors.Errorf("remove staging desktop: %w", err)
+	}
+	return nil
+}
+
+func (r *restorer) restoreDesktop(ctx context.Context, desktopID int, sd *Desktop) error {
+	if sd.Layout != "" {
+		if _, err := r.c.Exec(ctx, bspc.Desktop(bspc.DesktopID(desktopID)).Layout(bspc.Layout(sd.Layout))); err != nil {
+			return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+		}
+	}
+	if sd.Root == nil {
+		return nil
+	}
+	// Clear the desktop so that the receptacle tree can be built from an empty root.
+	d, err := wm.QueryDesktop(ctx, r.c, bspc.DesktopID(desktopID))
+	if err != nil {
+		return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+	}
+	var leaves []*wm.Node
+	d.Root.Walk(func(n *wm.Node) bool {
+		if n.FirstChild == nil && n.SecondChild == nil {
+			leaves = append(leaves, n)
+		}
+		return true
+	})
+	for _, n := range leaves {
+		if n.Client == nil {
+			if _, err := r.c.Exec(ctx, bspc.Node(bspc.NodeID(n.ID)).Kill()); err != nil {
+				return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+			}
+			continue
+		}
+		r.origins[n.ID] = desktopID
+		if _, err := r.c.Exec(ctx, bspc.Node(bspc.NodeID(n.ID)).ToDesktop(bspc.DesktopID(r.stagingID))); err != nil {
+			return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+		}
+	}
+	// The emptied desktop has no node to select, so the receptacle is inserted on the focused desktop.
+	if _, err := r.c.Exec(ctx, bspc.Desktop(bspc.DesktopID(desktopID)).Focus()); err != nil {
+		return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+	}
+	if _, err := r.c.Exec(ctx, bspc.Node().InsertReceptacle()); err != nil {
+		return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+	}
+	receptacles, err := r.receptacles(ctx, desktopID)
+	if err != nil {
+		return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+	}
+	if len(receptacles) != 1 {
+		return xerrors.Errorf("restore desktop %s: expected one receptacle, got %d", sd.Name, len(receptacles))
+	}
+	if err := r.buildNode(ctx, desktopID, receptacles[0], sd.Root); err != nil {
+		return xerrors.Errorf("restore desktop %s: %w", sd.Name, err)
+	}
+	return nil
+}
+
+func (r *restorer) buildNode(ctx context.Context, desktopID int, receptacleID int, n *Node) error {
+	if n.IsLeaf() {
+		if n.Client != nil {
+			r.placements = append(r.placements, &placement{
+				receptacleID: receptacleID,
+				desktopID:    desktopID,
+				client:       n.Client,
+			})
+		}
+		return nil
+	}
+	before, err := r.receptacles(ctx, desktopID)
+	if err != nil {
+		return xerrors.Errorf("build node: %w", err)
+	}
+	dir := bspc.DirSouth
+	if n.SplitType == "vertical" {
+		dir = bspc.DirEast
+	}
+	cmd := bspc.Node(bspc.NodeID(receptacleID)).PreselDir(dir)
+	if n.SplitRatio > 0 && n.SplitRatio < 1 {
+		cmd = cmd.PreselRatio(n.SplitRatio)
+	}
+	if _, err := r.c.Exec(ctx, cmd.InsertReceptacle()); err != nil {
+		return xerrors.Errorf("build node: %w", err)
+	}
+	after, err := r.receptacles(ctx, desktopID)
+	if err != nil {
+		return xerrors.Errorf("build node: %w", err)
+	}
+	secondID, ok := added(before, after)
+	if !ok {
+		return xerrors.Errorf("build node: no receptacle inserted at %d", receptacleID)
+	}
+	if n.FirstChild != nil {
+		if err := r.buildNode(ctx, desktopID, receptacleID, n.FirstChild); err != nil {
+			return err
+		}
+	}
+	if n.SecondChild != nil {
+		if err := r.buildNode(ctx, desktopID, secondID, n.SecondChild); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *restorer) receptacles(ctx context.Context, desktopID int) ([]int, error) {
+	return r.c.QueryNodes(
+		ctx,
+		bspc.AllNodes().With(bspc.NodeLeaf).Without(bspc.NodeWindow),
+		bspc.DesktopID(desktopID),
+	)
+}
+
+func (r *restorer) fillReceptacles(ctx context.Context) error {
+	state, err := wm.Load(ctx, r.c)
+	if err != nil {
+		return xerrors.Errorf("fill receptacles: %w", err)
+	}
+	windows := state.Clients()
+	for _, p := range r.placements {
+		if w, ok := r.findWindow(windows, p.client); ok {
+			r.placedWindow[w.Node.ID] = true
+			if _, err := r.c.Exec(ctx, bspc.Node(bspc.NodeID(w.Node.ID)).ToNode(bspc.NodeID(p.receptacleID))); err != nil {
+				return xerrors.Errorf("fill receptacles: %w", err)
+			}
+			continue
+		}
+		if len(p.client.Cmd) == 0 {
+			r.logger.Printf("no window or command for %s:%s", p.client.ClassName, p.client.InstanceName)
+			if _, err := r.c.Exec(ctx, bspc.Node(bspc.NodeID(p.receptacleID)).Kill()); err != nil {
+				return xerrors.Errorf("fill receptacles: %w", err)
+			}
+			continue
+		}
+		if err := r.launch(ctx, p); err != nil {
+			return xerrors.Errorf("fill receptacles: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *restorer) findWindow(windows []*wm.Location, c *Client) (*wm.Location, bool) {
+	for _, w := range windows {
+		if r.placedWindow[w.Node.ID] || r.saved[w.Desktop.ID] || w.Node.Hidden || w.Node.Client.State == "floating" {
+			continue
+		}
+		if w.Node.Client.ClassName == c.ClassName && w.Node.Client.InstanceName == c.InstanceName {
+			return w, true
+		}
+	}
+	return nil, false
+}
+
+func (r *restorer) launch(ctx context.Context, p *placement) error {
+	rule := bspc.Rule(p.client.ClassName, p.client.InstanceName).OneShot().Node(bspc.NodeID(p.receptacleID))
+	if _, err := r.c.Exec(ctx, rule); err != nil {
+		return xerrors.Errorf("launch %v: %w", p.client.Cmd, err)
+	}
+	r.logger.Printf("launching %v", p.client.Cmd)
+	cmd := exec.Command(p.client.Cmd[0], p.client.Cmd[1:]...)
+	currentUser, err := user.Current()
+	if err != nil {
+		return xerrors.Errorf("launch %v: %w", p.client.Cmd, err)
+	}
+	cmd.Dir = currentUser.HomeDir
+	if err := cmd.Start(); err != nil {
+		return xerrors.Errorf("launch %v: %w", p.client.Cmd, err)
+	}
+	if err := cmd.Process.Release(); err != nil {
+		return xerrors.Errorf("launch %v: %w", p.client.Cmd, err)
+	}
+	return nil
+}
+
+func added(before, after []int) (int, bool) {
+	seen := make(map[int]bool, len(before))
+	for _, id := range before {
+		seen[id] = true
+	}
+	for _, id := range after {
+		if !seen[id] {
+			return id, true
+		}
+	}
+	return 0, false
+}
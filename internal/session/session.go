@@ -0,0 +1,151 @@
+package session
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/odsod/bspwmrc/internal/wm"
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+const DefaultName = "default"
+
+type Session struct {
+	Monitors []*Monitor `json:"monitors"`
+}
+
+type Monitor struct {
+	Name     string     `json:"name"`
+	Desktops []*Desktop `json:"desktops"`
+}
+
+type Desktop struct {
+	Name   string `json:"name"`
+	Layout string `json:"layout"`
+	Root   *Node  `json:"root,omitempty"`
+}
+
+type Node struct {
+	SplitType   string  `json:"splitType,omitempty"`
+	SplitRatio  float64 `json:"splitRatio,omitempty"`
+	Client      *Client `json:"client,omitempty"`
+	FirstChild  *Node   `json:"firstChild,omitempty"`
+	SecondChild *Node   `json:"secondChild,omitempty"`
+}
+
+type Client struct {
+	ClassName    string   `json:"className"`
+	InstanceName string   `json:"instanceName"`
+	Cmd          []string `json:"cmd,omitempty"`
+}
+
+type CommandResolver func(windowID int) ([]string, error)
+
+func Capture(state *wm.State, resolveCmd CommandResolver) *Session {
+	var s Session
+	for _, m := range state.Monitors {
+		sm := &Monitor{Name: m.Name}
+		for _, d := range m.Desktops {
+			sm.Desktops = append(sm.Desktops, &Desktop{
+				Name:   d.Name,
+				Layout: d.Layout,
+				Root:   captureNode(d.Root, resolveCmd),
+			})
+		}
+		s.Monitors = append(s.Monitors, sm)
+	}
+	return &s
+}
+
+func captureNode(n *wm.Node, resolveCmd CommandResolver) *Node {
+	if n == nil {
+		return nil
+	}
+	if n.Client != nil {
+		// Hidden and floating clients are not part of the tiled layout.
+		if n.Hidden || n.Client.State == "floating" {
+			return nil
+		}
+		c := &Client{
+			ClassName:    n.Client.ClassName,
+			InstanceName: n.Client.InstanceName,
+		}
+		if resolveCmd != nil {
+			if cmd, err := resolveCmd(n.ID); err == nil {
+				c.Cmd = cmd
+			}
+		}
+		return &Node{Client: c}
+	}
+	first := captureNode(n.FirstChild, resolveCmd)
+	second := captureNode(n.SecondChild, resolveCmd)
+	switch {
+	case first == nil:
+		return second
+	case second == nil:
+		return first
+	}
+	return &Node{
+		SplitType:   n.SplitType,
+		SplitRatio:  n.SplitRatio,
+		FirstChild:  first,
+		SecondChild: second,
+	}
+}
+
+func (n *Node) IsLeaf() bool {
+	return n.FirstChild == nil && n.SecondChild == nil
+}
+
+func Path(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, "/\\") || strings.HasPrefix(name, ".") {
+		return "", xerrors.Errorf("session path: invalid name: %q", name)
+	}
+	stateHome, err := xdg.StateHome()
+	if err != nil {
+		return "", xerrors.Errorf("session path: %w", err)
+	}
+	return filepath.Join(stateHome, "bspwmrc", "sessions", name+".json"), nil
+}
+
+func Save(name string, s *Session) error {
+	p, err := Path(name)
+	if err != nil {
+		return xerrors.Errorf("save session: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return xerrors.Errorf("save session: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return xerrors.Errorf("save session: %w", err)
+	}
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return xerrors.Errorf("save session: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return xerrors.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+func Load(name string) (*Session, error) {
+	p, err := Path(name)
+	if err != nil {
+		return nil, xerrors.Errorf("load session: %w", err)
+	}
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, xerrors.Errorf("load session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, xerrors.Errorf("load session: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,291 @@
+package supervisor
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	"golang.org/x/xerrors"
+)
+
+type ReloadStrategy string
+
+const (
+	ReloadSignal         ReloadStrategy = "signal"
+	ReloadRestart        ReloadStrategy = "restart"
+	ReloadStartIfMissing ReloadStrategy = "start-if-missing"
+)
+
+type RestartPolicy string
+
+const (
+	RestartAlways    RestartPolicy = "always"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartNever     RestartPolicy = "never"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+	stopTimeout       = 5 * time.Second
+)
+
+type Service struct {
+	Name         string
+	Argv         []string
+	Env          map[string]string
+	Reload       ReloadStrategy
+	ReloadSignal syscall.Signal
+	Restart      RestartPolicy
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+}
+
+type Supervisor struct {
+	logger   *log.Logger
+	services []*service
+	ctx      context.Context
+	wg       sync.WaitGroup
+	started  chan struct{}
+}
+
+type service struct {
+	Service
+	mu         sync.Mutex
+	supervised bool
+	cmd        *exec.Cmd
+	restarting bool
+}
+
+func New(logger *log.Logger, services ...Service) *Supervisor {
+	s := &Supervisor{logger: logger, started: make(chan struct{})}
+	for _, svc := range services {
+		if svc.MinBackoff == 0 {
+			svc.MinBackoff = defaultMinBackoff
+		}
+		if svc.MaxBackoff == 0 {
+			svc.MaxBackoff = defaultMaxBackoff
+		}
+		s.services = append(s.services, &service{Service: svc})
+	}
+	return s
+}
+
+// Run starts all services and keeps them under supervision until the context is cancelled.
+func (s *Supervisor) Run(ctx context.Context) error {
+	s.ctx = ctx
+	for _, svc := range s.services {
+		if len(svc.Argv) == 0 {
+			return xerrors.Errorf("run supervisor: %s: empty argv", svc.Name)
+		}
+		external, err := findExternal(svc)
+		if err != nil {
+			return xerrors.Errorf("run supervisor: %w", err)
+		}
+		if len(external) > 0 && svc.Reload == ReloadStartIfMissing {
+			s.logger.Printf("%s: already running outside of supervision", svc.Name)
+			continue
+		}
+		for _, p := range external {
+			s.logger.Printf("%s: killing unsupervised process %d", svc.Name, p.Pid)
+			if err := p.Kill(); err != nil {
+				return xerrors.Errorf("run supervisor: %s: %w", svc.Name, err)
+			}
+		}
+		s.supervise(svc)
+	}
+	close(s.started)
+	<-ctx.Done()
+	s.wg.Wait()
+	return nil
+}
+
+// Started is closed once Run has started all services.
+func (s *Supervisor) Started() <-chan struct{} {
+	return s.started
+}
+
+// Reload reloads all services. Before Run has started them it does nothing, since they are about to start.
+func (s *Supervisor) Reload() error {
+	select {
+	case <-s.started:
+	default:
+		return nil
+	}
+	for _, svc := range s.services {
+		if err := s.reload(svc); err != nil {
+			return xerrors.Errorf("reload services: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) reload(svc *service) error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	switch svc.Reload {
+	case ReloadSignal:
+		if svc.cmd == nil {
+			return nil
+		}
+		s.logger.Printf("%s: reloading with %v", svc.Name, svc.ReloadSignal)
+		if err := svc.cmd.Process.Signal(svc.ReloadSignal); err != nil {
+			return xerrors.Errorf("reload %s: %w", svc.Name, err)
+		}
+	case ReloadRestart:
+		if svc.cmd == nil {
+			return nil
+		}
+		s.logger.Printf("%s: restarting", svc.Name)
+		svc.restarting = true
+		if err := svc.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			return xerrors.Errorf("reload %s: %w", svc.Name, err)
+		}
+	case ReloadStartIfMissing:
+		if svc.supervised {
+			return nil
+		}
+		external, err := findExternal(svc)
+		if err != nil {
+			return xerrors.Errorf("reload %s: %w", svc.Name, err)
+		}
+		if len(external) == 0 {
+			s.superviseLocked(svc)
+		}
+	default:
+		return xerrors.Errorf("reload %s: unknown strategy: %s", svc.Name, svc.Reload)
+	}
+	return nil
+}
+
+func (s *Supervisor) supervise(svc *service) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.supervised {
+		return
+	}
+	s.superviseLocked(svc)
+}
+
+func (s *Supervisor) superviseLocked(svc *service) {
+	svc.supervised = true
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(svc)
+		svc.mu.Lock()
+		svc.supervised = false
+		svc.mu.Unlock()
+	}()
+}
+
+func (s *Supervisor) loop(svc *service) {
+	backoff := svc.MinBackoff
+	for {
+		started := time.Now()
+		err := s.runOnce(svc)
+		if s.ctx.Err() != nil {
+			return
+		}
+		svc.mu.Lock()
+		restarting := svc.restarting
+		svc.restarting = false
+		svc.mu.Unlock()
+		if restarting {
+			backoff = svc.MinBackoff
+			continue
+		}
+		s.logger.Printf("%s: exited: %v", svc.Name, err)
+		switch {
+		case svc.Restart == RestartNever:
+			return
+		case svc.Restart == RestartOnFailure && err == nil:
+			return
+		}
+		if time.Since(started) > svc.MaxBackoff {
+			backoff = svc.MinBackoff
+		}
+		s.logger.Printf("%s: restarting in %v", svc.Name, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > svc.MaxBackoff {
+			backoff = svc.MaxBackoff
+		}
+	}
+}
+
+func (s *Supervisor) runOnce(svc *service) error {
+	cmd := exec.Command(svc.Argv[0], svc.Argv[1:]...)
+	if len(svc.Env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range svc.Env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+	}
+	s.logger.Printf("%s: starting %v", svc.Name, svc.Argv)
+	if err := cmd.Start(); err != nil {
+		return xerrors.Errorf("start %s: %w", svc.Name, err)
+	}
+	svc.mu.Lock()
+	svc.cmd = cmd
+	svc.mu.Unlock()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	var err error
+	select {
+	case err = <-exited:
+	case <-s.ctx.Done():
+		err = stop(cmd, exited)
+	}
+	svc.mu.Lock()
+	svc.cmd = nil
+	svc.mu.Unlock()
+	return err
+}
+
+func stop(cmd *exec.Cmd, exited <-chan error) error {
+	_ = cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(stopTimeout):
+		_ = cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+func findExternal(svc *service) ([]*process.Process, error) {
+	ps, err := process.Processes()
+	if err != nil {
+		return nil, xerrors.Errorf("find %s: %w", svc.Name, err)
+	}
+	name := filepath.Base(svc.Argv[0])
+	self := int32(os.Getpid())
+	var result []*process.Process
+	for _, p := range ps {
+		parent, err := p.Ppid()
+		if err == nil && parent == self {
+			continue
+		}
+		pName, err := p.Name()
+		if err != nil {
+			// The process may have exited since it was listed.
+			continue
+		}
+		if pName == name {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package sysfs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+func ReadStr(f string) (string, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return "", xerrors.Errorf("read str: %w", err)
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+func ReadInt(f string) (int, error) {
+	s, err := ReadStr(f)
+	if err != nil {
+		return 0, xerrors.Errorf("read int: %w", err)
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, xerrors.Errorf("read int: %w", err)
+	}
+	return i, nil
+}
@@ -0,0 +1,74 @@
+package wallpaper
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/odsod/bspwmrc/internal/xdg"
+	"golang.org/x/xerrors"
+)
+
+func Dir() (string, error) {
+	configHome, err := xdg.ConfigHome()
+	if err != nil {
+		return "", xerrors.Errorf("wallpaper dir: %w", err)
+	}
+	return filepath.Join(configHome, "bspwmrc", "wallpapers"), nil
+}
+
+// List returns the images in dir in lexical order, or nothing when dir does not exist.
+func List(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("list wallpapers: %w", err)
+	}
+	var result []string
+	for _, fi := range fis {
+		switch strings.ToLower(filepath.Ext(fi.Name())) {
+		case ".png", ".jpg", ".jpeg":
+			result = append(result, filepath.Join(dir, fi.Name()))
+		}
+	}
+	return result, nil
+}
+
+func Set(path string) error {
+	if err := exec.Command("feh", "--bg-scale", path).Run(); err != nil {
+		return xerrors.Errorf("set wallpaper %s: %w", path, err)
+	}
+	return nil
+}
+
+type Rotator struct {
+	Dir     string
+	current string
+}
+
+// Next sets the wallpaper following the current one, wrapping around at the end of the directory.
+func (r *Rotator) Next() error {
+	paths, err := List(r.Dir)
+	if err != nil {
+		return xerrors.Errorf("rotate wallpaper: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	next := paths[0]
+	for i, p := range paths {
+		if p == r.current && i+1 < len(paths) {
+			next = paths[i+1]
+			break
+		}
+	}
+	if err := Set(next); err != nil {
+		return xerrors.Errorf("rotate wallpaper: %w", err)
+	}
+	r.current = next
+	return nil
+}
@@ -0,0 +1,171 @@
+package wm
+
+import "fmt"
+
+type ChangeKind string
+
+const (
+	NodeAdded     ChangeKind = "node_added"
+	NodeRemoved   ChangeKind = "node_removed"
+	NodeMoved     ChangeKind = "node_moved"
+	FlagChanged   ChangeKind = "flag_changed"
+	FocusChanged  ChangeKind = "focus_changed"
+	LayoutChanged ChangeKind = "layout_changed"
+)
+
+type Change struct {
+	Kind      ChangeKind
+	MonitorID int
+	DesktopID int
+	NodeID    int
+	// Field is the flag name for FlagChanged, the focus level (monitor, desktop or node) for
+	// FocusChanged and the location level (monitor or desktop) for NodeMoved.
+	Field string
+	Old   string
+	New   string
+}
+
+func (c *Change) String() string {
+	switch c.Kind {
+	case NodeAdded, NodeRemoved:
+		return fmt.Sprintf("%s node=%s desktop=%s monitor=%s",
+			c.Kind, formatID(c.NodeID), formatID(c.DesktopID), formatID(c.MonitorID))
+	case NodeMoved, FlagChanged:
+		return fmt.Sprintf("%s node=%s %s: %s -> %s", c.Kind, formatID(c.NodeID), c.Field, c.Old, c.New)
+	case FocusChanged:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Field, c.Old, c.New)
+	case LayoutChanged:
+		return fmt.Sprintf("%s desktop=%s: %s -> %s", c.Kind, formatID(c.DesktopID), c.Old, c.New)
+	default:
+		return fmt.Sprintf("%s %+v", c.Kind, *c)
+	}
+}
+
+func Diff(prev, next *State) []*Change {
+	var changes []*Change
+	prevClients := clientsByID(prev)
+	nextClients := clientsByID(next)
+	for _, l := range next.Clients() {
+		p, ok := prevClients[l.Node.ID]
+		if !ok {
+			changes = append(changes, locationChange(NodeAdded, l))
+			continue
+		}
+		if p.Monitor.ID != l.Monitor.ID {
+			changes = append(changes, nodeChange(NodeMoved, l, "monitor", formatID(p.Monitor.ID), formatID(l.Monitor.ID)))
+		}
+		if p.Desktop.ID != l.Desktop.ID {
+			changes = append(changes, nodeChange(NodeMoved, l, "desktop", formatID(p.Desktop.ID), formatID(l.Desktop.ID)))
+		}
+		for _, f := range []struct {
+			name       string
+			prev, next bool
+		}{
+			{name: "hidden", prev: p.Node.Hidden, next: l.Node.Hidden},
+			{name: "sticky", prev: p.Node.Sticky, next: l.Node.Sticky},
+			{name: "private", prev: p.Node.Private, next: l.Node.Private},
+			{name: "locked", prev: p.Node.Locked, next: l.Node.Locked},
+		} {
+			if f.prev != f.next {
+				changes = append(changes, nodeChange(FlagChanged, l, f.name, onOff(f.prev), onOff(f.next)))
+			}
+		}
+	}
+	for _, l := range prev.Clients() {
+		if _, ok := nextClients[l.Node.ID]; !ok {
+			changes = append(changes, locationChange(NodeRemoved, l))
+		}
+	}
+	if prev.FocusedMonitorID != next.FocusedMonitorID {
+		changes = append(changes, &Change{
+			Kind:      FocusChanged,
+			MonitorID: next.FocusedMonitorID,
+			Field:     "monitor",
+			Old:       formatID(prev.FocusedMonitorID),
+			New:       formatID(next.FocusedMonitorID),
+		})
+	}
+	prevMonitors := make(map[int]*Monitor, len(prev.Monitors))
+	prevDesktops := make(map[int]*Desktop)
+	for _, m := range prev.Monitors {
+		prevMonitors[m.ID] = m
+		for _, d := range m.Desktops {
+			prevDesktops[d.ID] = d
+		}
+	}
+	for _, m := range next.Monitors {
+		if p, ok := prevMonitors[m.ID]; ok && p.FocusedDesktopID != m.FocusedDesktopID {
+			changes = append(changes, &Change{
+				Kind:      FocusChanged,
+				MonitorID: m.ID,
+				DesktopID: m.FocusedDesktopID,
+				Field:     "desktop",
+				Old:       formatID(p.FocusedDesktopID),
+				New:       formatID(m.FocusedDesktopID),
+			})
+		}
+		for _, d := range m.Desktops {
+			p, ok := prevDesktops[d.ID]
+			if !ok {
+				continue
+			}
+			if p.FocusedNodeID != d.FocusedNodeID {
+				changes = append(changes, &Change{
+					Kind:      FocusChanged,
+					MonitorID: m.ID,
+					DesktopID: d.ID,
+					NodeID:    d.FocusedNodeID,
+					Field:     "node",
+					Old:       formatID(p.FocusedNodeID),
+					New:       formatID(d.FocusedNodeID),
+				})
+			}
+			if p.Layout != d.Layout {
+				changes = append(changes, &Change{
+					Kind:      LayoutChanged,
+					MonitorID: m.ID,
+					DesktopID: d.ID,
+					Old:       p.Layout,
+					New:       d.Layout,
+				})
+			}
+		}
+	}
+	return changes
+}
+
+func clientsByID(s *State) map[int]*Location {
+	result := make(map[int]*Location)
+	for _, l := range s.Clients() {
+		result[l.Node.ID] = l
+	}
+	return result
+}
+
+func locationChange(kind ChangeKind, l *Location) *Change {
+	return &Change{
+		Kind:      kind,
+		MonitorID: l.Monitor.ID,
+		DesktopID: l.Desktop.ID,
+		NodeID:    l.Node.ID,
+	}
+}
+
+func nodeChange(kind ChangeKind, l *Location, field, oldValue, newValue string) *Change {
+	c := locationChange(kind, l)
+	c.Field = field
+	c.Old = oldValue
+	c.New = newValue
+	return c
+}
+
+func formatID(id int) string {
+	return fmt.Sprintf("0x%08X", id)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
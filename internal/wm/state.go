@@ -1,6 +1,7 @@
 package wm
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/odsod/bspwmrc/internal/bspc"
@@ -55,6 +56,7 @@ type Node struct {
 	Client        *Client     `json:"client"`
 	FirstChild    *Node       `json:"firstChild"`
 	SecondChild   *Node       `json:"secondChild"`
+	x             *windowProperties
 }
 
 type Client struct {
@@ -91,7 +93,15 @@ type Constraints struct {
 }
 
 func LoadState() (*State, error) {
-	response, err := bspc.Run("wm", "-d")
+	c, err := bspc.NewClient()
+	if err != nil {
+		return nil, xerrors.Errorf("load wm state: %w", err)
+	}
+	return Load(context.Background(), c)
+}
+
+func Load(ctx context.Context, c *bspc.Client) (*State, error) {
+	response, err := c.Run(ctx, "wm", "-d")
 	if err != nil {
 		return nil, xerrors.Errorf("load wm state: %w", err)
 	}
@@ -102,16 +112,56 @@ func LoadState() (*State, error) {
 	return state, nil
 }
 
-func (s *State) FocusedDesktop() (*Desktop, error) {
+func (s *State) FocusedMonitor() (*Monitor, error) {
 	for _, m := range s.Monitors {
 		if m.ID == s.FocusedMonitorID {
-			for _, d := range m.Desktops {
-				if d.ID == m.FocusedDesktopID {
-					return d, nil
-				}
-			}
-			return nil, xerrors.Errorf("no desktop for id: %v", m.FocusedDesktopID)
+			return m, nil
 		}
 	}
 	return nil, xerrors.Errorf("no monitor for id: %v", s.FocusedMonitorID)
 }
+
+func (s *State) FocusedDesktop() (*Desktop, error) {
+	m, err := s.FocusedMonitor()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range m.Desktops {
+		if d.ID == m.FocusedDesktopID {
+			return d, nil
+		}
+	}
+	return nil, xerrors.Errorf("no desktop for id: %v", m.FocusedDesktopID)
+}
+
+func QueryNode(ctx context.Context, c *bspc.Client, sel bspc.NodeSelector) (*Node, error) {
+	var node *Node
+	if err := queryTree(ctx, c, sel, &node); err != nil {
+		return nil, xerrors.Errorf("query node: %w", err)
+	}
+	return node, nil
+}
+
+func QueryDesktop(ctx context.Context, c *bspc.Client, sel bspc.DesktopSelector) (*Desktop, error) {
+	var desktop *Desktop
+	if err := queryTree(ctx, c, sel, &desktop); err != nil {
+		return nil, xerrors.Errorf("query desktop: %w", err)
+	}
+	return desktop, nil
+}
+
+func QueryMonitor(ctx context.Context, c *bspc.Client, sel bspc.MonitorSelector) (*Monitor, error) {
+	var monitor *Monitor
+	if err := queryTree(ctx, c, sel, &monitor); err != nil {
+		return nil, xerrors.Errorf("query monitor: %w", err)
+	}
+	return monitor, nil
+}
+
+func queryTree(ctx context.Context, c *bspc.Client, sel bspc.Selector, v interface{}) error {
+	response, err := c.QueryTree(ctx, sel)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(response, v)
+}
@@ -0,0 +1,157 @@
+package wm
+
+type Location struct {
+	Node    *Node
+	Desktop *Desktop
+	Monitor *Monitor
+}
+
+type Predicate func(*Node) bool
+
+func (n *Node) Walk(fn func(*Node) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !fn(n) {
+		return false
+	}
+	return n.FirstChild.Walk(fn) && n.SecondChild.Walk(fn)
+}
+
+func (n *Node) Find(pred Predicate) (*Node, bool) {
+	var result *Node
+	n.Walk(func(n *Node) bool {
+		if pred(n) {
+			result = n
+			return false
+		}
+		return true
+	})
+	return result, result != nil
+}
+
+func (s *State) Walk(fn func(*Location) bool) bool {
+	for _, m := range s.Monitors {
+		for _, d := range m.Desktops {
+			if !d.Root.Walk(func(n *Node) bool {
+				return fn(&Location{Node: n, Desktop: d, Monitor: m})
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *State) Clients() []*Location {
+	var result []*Location
+	s.Walk(func(l *Location) bool {
+		if l.Node.Client != nil {
+			result = append(result, l)
+		}
+		return true
+	})
+	return result
+}
+
+func (s *State) FindNode(pred Predicate) (*Location, bool) {
+	var result *Location
+	s.Walk(func(l *Location) bool {
+		if pred(l.Node) {
+			result = l
+			return false
+		}
+		return true
+	})
+	return result, result != nil
+}
+
+func (s *State) NodeByID(id int) (*Location, bool) {
+	return s.FindNode(ByID(id))
+}
+
+func (s *State) DesktopByName(name string) (*Desktop, *Monitor, bool) {
+	for _, m := range s.Monitors {
+		for _, d := range m.Desktops {
+			if d.Name == name {
+				return d, m, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+func (s *State) MonitorByName(name string) (*Monitor, bool) {
+	for _, m := range s.Monitors {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func ByID(id int) Predicate {
+	return func(n *Node) bool {
+		return n.ID == id
+	}
+}
+
+func ByClass(className string) Predicate {
+	return func(n *Node) bool {
+		return n.Client != nil && n.Client.ClassName == className
+	}
+}
+
+func ByInstance(instanceName string) Predicate {
+	return func(n *Node) bool {
+		return n.Client != nil && n.Client.InstanceName == instanceName
+	}
+}
+
+func IsClient(n *Node) bool {
+	return n.Client != nil
+}
+
+func Hidden(n *Node) bool {
+	return n.Hidden
+}
+
+func Sticky(n *Node) bool {
+	return n.Sticky
+}
+
+func Floating(n *Node) bool {
+	return n.Client != nil && n.Client.State == "floating"
+}
+
+func Urgent(n *Node) bool {
+	return n.Client != nil && n.Client.Urgent
+}
+
+func And(preds ...Predicate) Predicate {
+	return func(n *Node) bool {
+		for _, pred := range preds {
+			if !pred(n) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func Or(preds ...Predicate) Predicate {
+	return func(n *Node) bool {
+		for _, pred := range preds {
+			if pred(n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func Not(pred Predicate) Predicate {
+	return func(n *Node) bool {
+		return !pred(n)
+	}
+}
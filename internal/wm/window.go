@@ -0,0 +1,42 @@
+package wm
+
+import "github.com/odsod/bspwmrc/internal/xprop"
+
+// windowProperties caches the X properties of a node that bspwm does not report, since each read runs xprop.
+type windowProperties struct {
+	titleRead    bool
+	title        string
+	titleErr     error
+	classRead    bool
+	instanceName string
+	className    string
+	classErr     error
+}
+
+func (n *Node) properties() *windowProperties {
+	if n.x == nil {
+		n.x = &windowProperties{}
+	}
+	return n.x
+}
+
+// Title returns the window title, read once per node.
+func (n *Node) Title() (string, error) {
+	p := n.properties()
+	if !p.titleRead {
+		p.title, p.titleErr = xprop.Title(n.ID)
+		p.titleRead = true
+	}
+	return p.title, p.titleErr
+}
+
+// WMClass returns the instance and class names of the window as currently set, read once per node. They
+// differ from the client names when bspwm recorded the window before WM_CLASS was set.
+func (n *Node) WMClass() (string, string, error) {
+	p := n.properties()
+	if !p.classRead {
+		p.instanceName, p.className, p.classErr = xprop.Class(n.ID)
+		p.classRead = true
+	}
+	return p.instanceName, p.className, p.classErr
+}
@@ -0,0 +1,52 @@
+package xdg
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+// ConfigFile is the content of a bspwmrc config file.
+type ConfigFile struct {
+	Path string
+	Data []byte
+}
+
+// ConfigPath returns the path of the named bspwmrc config file.
+func ConfigPath(name string) (string, error) {
+	configHome, err := ConfigHome()
+	if err != nil {
+		return "", xerrors.Errorf("%s path: %w", name, err)
+	}
+	return filepath.Join(configHome, "bspwmrc", name), nil
+}
+
+// ReadConfig reads the named bspwmrc config file, returning nil when it does not exist.
+func ReadConfig(name string) (*ConfigFile, error) {
+	p, err := ConfigPath(name)
+	if err != nil {
+		return nil, xerrors.Errorf("read config: %w", err)
+	}
+	data, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("read config: %w", err)
+	}
+	return &ConfigFile{Path: p, Data: data}, nil
+}
+
+// Decode decodes the JSON config into v, rejecting unknown fields.
+func (f *ConfigFile) Decode(v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(f.Data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return xerrors.Errorf("parse %s: %w", f.Path, err)
+	}
+	return nil
+}
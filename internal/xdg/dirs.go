@@ -0,0 +1,36 @@
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+)
+
+func ConfigHome() (string, error) {
+	return fromEnv("XDG_CONFIG_HOME", ".config")
+}
+
+func StateHome() (string, error) {
+	return fromEnv("XDG_STATE_HOME", ".local/state")
+}
+
+func RuntimeDir() (string, error) {
+	if dir, ok := os.LookupEnv("XDG_RUNTIME_DIR"); ok && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("bspwmrc-%d", os.Getuid())), nil
+}
+
+func fromEnv(key string, homeRelative string) (string, error) {
+	if dir, ok := os.LookupEnv(key); ok && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	currentUser, err := user.Current()
+	if err != nil {
+		return "", xerrors.Errorf("resolve %s: %w", key, err)
+	}
+	return filepath.Join(currentUser.HomeDir, homeRelative), nil
+}
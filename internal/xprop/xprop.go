@@ -0,0 +1,68 @@
+package xprop
+
+import (
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+func Get(windowID int, property string) (string, error) {
+	output, err := exec.Command("xprop", "-notype", "-id", strconv.Itoa(windowID), property).Output()
+	if err != nil {
+		return "", xerrors.Errorf("xprop %s: %w", property, err)
+	}
+	line := string(bytes.TrimSpace(output))
+	parts := strings.SplitN(line, " = ", 2)
+	if len(parts) != 2 || parts[0] != property {
+		return "", xerrors.Errorf("xprop %s: %s", property, line)
+	}
+	return parts[1], nil
+}
+
+func PID(windowID int) (int, error) {
+	value, err := Get(windowID, "_NET_WM_PID")
+	if err != nil {
+		return 0, xerrors.Errorf("xprop pid: %w", err)
+	}
+	pid, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, xerrors.Errorf("xprop pid: %w", err)
+	}
+	return pid, nil
+}
+
+func Title(windowID int) (string, error) {
+	value, err := Get(windowID, "_NET_WM_NAME")
+	if err != nil {
+		return "", xerrors.Errorf("xprop title: %w", err)
+	}
+	title, err := strconv.Unquote(value)
+	if err != nil {
+		return strings.Trim(value, `"`), nil
+	}
+	return title, nil
+}
+
+// Class returns the instance and class names of WM_CLASS.
+func Class(windowID int) (string, string, error) {
+	value, err := Get(windowID, "WM_CLASS")
+	if err != nil {
+		return "", "", xerrors.Errorf("xprop class: %w", err)
+	}
+	parts := strings.SplitN(value, ", ", 2)
+	if len(parts) != 2 {
+		return "", "", xerrors.Errorf("xprop class: %s", value)
+	}
+	instanceName, err := strconv.Unquote(parts[0])
+	if err != nil {
+		return "", "", xerrors.Errorf("xprop class: %w", err)
+	}
+	className, err := strconv.Unquote(parts[1])
+	if err != nil {
+		return "", "", xerrors.Errorf("xprop class: %w", err)
+	}
+	return instanceName, className, nil
+}